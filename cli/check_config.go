@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/mcuadros/dockership/config"
+)
+
+func runCheckConfig(c *Client, o *options, args []string) int {
+	subscribeEvents(o.stderr, o.verbose)
+
+	var cfg config.Config
+	if err := cfg.ReadFile(o.config); err != nil {
+		fmt.Fprintf(o.stderr, "Error: %s\n", err)
+		return ExitFailure
+	}
+
+	problems := cfg.Validate()
+	if o.json {
+		printJSON(o, problems)
+	} else {
+		for _, p := range problems {
+			level := "error"
+			if p.Warning {
+				level = "warning"
+			}
+
+			fmt.Fprintf(o.stdout, "%s: %s\n", level, p)
+		}
+	}
+
+	errs := len(problems.Errors())
+	if !o.json {
+		fmt.Fprintf(o.stdout, "%s: %d error(s), %d warning(s)\n", o.config, errs, len(problems)-errs)
+	}
+
+	if errs != 0 {
+		return ExitFailure
+	}
+
+	return ExitOK
+}
@@ -0,0 +1,186 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/mcuadros/dockership/config"
+)
+
+// Exit codes, the failed operations (a deploy that did not succeed, a project
+// with errors) exit with ExitFailure, any problem talking to the daemon with
+// ExitError
+const (
+	ExitOK      = 0
+	ExitFailure = 1
+	ExitUsage   = 2
+	ExitError   = 3
+)
+
+type command struct {
+	name  string
+	args  string
+	help  string
+	flags func(f *flag.FlagSet, o *options)
+	run   func(c *Client, o *options, args []string) int
+}
+
+var commands = []*command{
+	{name: "login", args: "", help: "stores the server and the API token or session", run: runLogin,
+		flags: func(f *flag.FlagSet, o *options) {
+			f.StringVar(&o.session, "session", "", "browser session cookie, instead of an API token")
+		}},
+	{name: "projects", help: "lists the projects", run: runProjects},
+	{name: "status", args: "[project]", help: "shows the status of the projects", run: runStatus},
+	{name: "containers", args: "<project>", help: "lists the containers of a project", run: runContainers},
+	{name: "deploy", args: "<project> <environment>", help: "deploys a project, streaming its output", run: runDeploy},
+	{name: "rollback", args: "<project> <environment>", help: "runs a previous revision of a project", run: runRollback,
+		flags: func(f *flag.FlagSet, o *options) {
+			f.StringVar(&o.revision, "revision", "", "revision to rollback to, by default the previous one")
+		}},
+	{name: "logs", args: "<project> <environment>", help: "shows the logs of the containers", run: runLogs,
+		flags: func(f *flag.FlagSet, o *options) {
+			f.BoolVar(&o.follow, "f", false, "follow the log output")
+			f.StringVar(&o.tail, "tail", "all", "number of lines to show from the end of the logs")
+			f.StringVar(&o.since, "since", "", "show logs since a unix timestamp or a RFC3339 date")
+		}},
+	{name: "local deploy", args: "<project> <environment>", help: "deploys a project without a daemon", run: runLocalDeploy,
+		flags: localFlags},
+	{name: "local status", args: "<project> [environment]", help: "shows the status of a project without a daemon", run: runLocalStatus,
+		flags: localFlags},
+	{name: "local clean", args: "<project> <environment>", help: "removes the containers and old images of a project without a daemon", run: runLocalClean,
+		flags: localFlags},
+	{name: "check-config", help: "validates the config file, exits with 1 on errors", run: runCheckConfig,
+		flags: localFlags},
+	{name: "convert-config", args: "<yaml|json|toml>", help: "converts the INI config file to another format", run: runConvertConfig,
+		flags: localFlags},
+	{name: "history", help: "lists the deploys", run: runHistory,
+		flags: func(f *flag.FlagSet, o *options) {
+			f.StringVar(&o.project, "project", "", "filter by project")
+			f.StringVar(&o.environment, "environment", "", "filter by environment")
+			f.StringVar(&o.user, "user", "", "filter by user")
+			f.IntVar(&o.limit, "limit", 20, "max number of deploys")
+		}},
+}
+
+func localFlags(f *flag.FlagSet, o *options) {
+	f.StringVar(&o.config, "config", config.DefaultConfig, "config file")
+	f.BoolVar(&o.verbose, "v", false, "writes the debug events")
+}
+
+type options struct {
+	server, token, session string
+	json                   bool
+	revision               string
+	follow                 bool
+	tail, since            string
+	project, environment   string
+	user                   string
+	limit                  int
+	config                 string
+	verbose                bool
+	stdout, stderr         io.Writer
+}
+
+// Start runs the command given at the arguments and exits with its exit code
+func Start(version, build string) {
+	os.Exit(Main(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// Main runs the command given at args returning the exit code
+func Main(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return ExitUsage
+	}
+
+	cmd := getCommand(args)
+	if cmd == nil {
+		fmt.Fprintf(stderr, "Unknown command %q\n\n", args[0])
+		usage(stderr)
+		return ExitUsage
+	}
+
+	args = args[len(strings.Fields(cmd.name)):]
+
+	o := &options{stdout: stdout, stderr: stderr}
+	f := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	f.SetOutput(stderr)
+	f.StringVar(&o.server, "server", "", "dockership server URL (default: DOCKERSHIP_SERVER or the stored one)")
+	f.StringVar(&o.token, "token", "", "API token (default: DOCKERSHIP_TOKEN or the stored one)")
+	f.BoolVar(&o.json, "json", false, "prints the response as JSON")
+	if cmd.flags != nil {
+		cmd.flags(f, o)
+	}
+
+	f.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: dockership %s [options] %s\n\n", cmd.name, cmd.args)
+		f.PrintDefaults()
+	}
+
+	if err := f.Parse(args); err != nil {
+		return ExitUsage
+	}
+
+	min := strings.Count(cmd.args, "<")
+	if f.NArg() < min || f.NArg() > min+strings.Count(cmd.args, "[") {
+		f.Usage()
+		return ExitUsage
+	}
+
+	creds := LoadCredentials()
+	if o.server != "" {
+		creds.Server = o.server
+	}
+
+	if o.token != "" {
+		creds.Token = o.token
+		creds.Session = ""
+	}
+
+	return cmd.run(NewClient(creds), o, f.Args())
+}
+
+// getCommand returns the command at the start of args, the local commands are
+// two words long
+func getCommand(args []string) *command {
+	for _, c := range commands {
+		name := strings.Fields(c.name)
+		if len(args) >= len(name) && strings.Join(args[:len(name)], " ") == c.name {
+			return c
+		}
+	}
+
+	return nil
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: dockership <command> [options] [arguments]\n\nCommands:")
+	t := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	for _, c := range commands {
+		fmt.Fprintf(t, "  %s %s\t%s\n", c.name, c.args, c.help)
+	}
+
+	t.Flush()
+}
+
+func fail(o *options, err error) int {
+	fmt.Fprintf(o.stderr, "Error: %s\n", err)
+	return ExitError
+}
+
+func printJSON(o *options, v interface{}) {
+	raw, _ := json.MarshalIndent(v, "", "  ")
+	fmt.Fprintf(o.stdout, "%s\n", raw)
+}
+
+func newTable(o *options, columns ...string) *tabwriter.Writer {
+	t := tabwriter.NewWriter(o.stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(t, strings.Join(columns, "\t"))
+	return t
+}
@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type CLISuite struct {
+	server         *httptest.Server
+	stdout, stderr *bytes.Buffer
+}
+
+var _ = Suite(&CLISuite{})
+
+func (s *CLISuite) SetUpTest(c *C) {
+	os.Setenv("HOME", c.MkDir())
+	s.stdout = bytes.NewBuffer(nil)
+	s.stderr = bytes.NewBuffer(nil)
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer foo" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/rest/deploy/foo/live":
+			fmt.Fprintln(w, `{"project":"foo","environment":"live","endpoint":"tcp://bar","log":"Step 1"}`)
+			fmt.Fprintln(w, `{"Done":true,"Elapsed":1000}`)
+		case "/rest/deploy/foo/dev":
+			fmt.Fprintln(w, `{"Done":false,"Elapsed":1000,"Errors":[{}]}`)
+		case "/rest/status/foo":
+			fmt.Fprintln(w, `{"foo":{"Status":{"live":{"LastRevisionLabel":"qux"}}}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintln(w, `{"Error":"Project not found"}`)
+		}
+	}))
+}
+
+func (s *CLISuite) TearDownTest(c *C) {
+	s.server.Close()
+}
+
+func (s *CLISuite) run(args ...string) int {
+	return Main(append(args[:1], append([]string{"--server", s.server.URL, "--token", "foo"}, args[1:]...)...), s.stdout, s.stderr)
+}
+
+func (s *CLISuite) TestRun_Usage(c *C) {
+	c.Assert(Main(nil, s.stdout, s.stderr), Equals, ExitUsage)
+	c.Assert(Main([]string{"foo"}, s.stdout, s.stderr), Equals, ExitUsage)
+	c.Assert(Main([]string{"deploy", "foo"}, s.stdout, s.stderr), Equals, ExitUsage)
+}
+
+func (s *CLISuite) TestRun_Deploy(c *C) {
+	c.Assert(s.run("deploy", "foo", "live"), Equals, ExitOK)
+	c.Assert(s.stdout.String(), Equals, "tcp://bar | Step 1\n")
+}
+
+func (s *CLISuite) TestRun_DeployFailed(c *C) {
+	c.Assert(s.run("deploy", "foo", "dev"), Equals, ExitFailure)
+}
+
+func (s *CLISuite) TestRun_Status(c *C) {
+	c.Assert(s.run("status", "foo"), Equals, ExitOK)
+	c.Assert(s.stdout.String(), Matches, "(?s).*foo +live +- +qux +not running.*")
+}
+
+func (s *CLISuite) TestRun_Error(c *C) {
+	c.Assert(s.run("rollback", "qux", "live"), Equals, ExitError)
+	c.Assert(s.stderr.String(), Equals, "Error: Project not found\n")
+}
+
+func (s *CLISuite) TestRun_NotAuthenticated(c *C) {
+	code := Main([]string{"status", "--server", s.server.URL, "--token", "bar"}, s.stdout, s.stderr)
+	c.Assert(code, Equals, ExitError)
+	c.Assert(s.stderr.String(), Equals, fmt.Sprintf("Error: %s\n", ErrNotAuthenticated))
+}
+
+func (s *CLISuite) TestRun_LocalProjectNotFound(c *C) {
+	file := filepath.Join(c.MkDir(), "dockership.conf")
+	c.Assert(ioutil.WriteFile(file, []byte("[Project \"foo\"]\nRepository = git@github.com:foo/foo.git\n"), 0644), IsNil)
+
+	code := Main([]string{"local", "deploy", "--config", file, "bar", "live"}, s.stdout, s.stderr)
+	c.Assert(code, Equals, ExitError)
+	c.Assert(s.stderr.String(), Matches, "(?s).*Project \"bar\" not found.*")
+}
+
+func (s *CLISuite) TestRun_CheckConfig(c *C) {
+	file := filepath.Join(c.MkDir(), "dockership.conf")
+	c.Assert(ioutil.WriteFile(file, []byte("[Project \"foo\"]\nRepository = git@github.com:foo/foo.git\nEnvironment = live\n"), 0644), IsNil)
+
+	code := Main([]string{"check-config", "--config", file}, s.stdout, s.stderr)
+	c.Assert(code, Equals, ExitFailure)
+	c.Assert(s.stdout.String(), Matches, `(?s)error: .*/dockership.conf: Project "foo": Environment: unknown environment "live"\n.*1 error\(s\), 0 warning\(s\)\n`)
+
+	s.stdout.Reset()
+	c.Assert(ioutil.WriteFile(file, []byte("[Project \"foo\"]\nRepository = git@github.com:foo/foo.git\n"), 0644), IsNil)
+	c.Assert(Main([]string{"check-config", "--config", file}, s.stdout, s.stderr), Equals, ExitOK)
+	c.Assert(s.stdout.String(), Matches, `(?s)warning: .*/dockership.conf: Project "foo": Environment: no environment defined.*`)
+}
+
+func (s *CLISuite) TestRun_ConvertConfig(c *C) {
+	code := Main([]string{"convert-config", "--config", "../example/config.ini", "json"}, s.stdout, s.stderr)
+	c.Assert(code, Equals, ExitOK)
+	c.Assert(s.stdout.String(), Matches, `(?s)\{\n  "Environment": \{.*"GithubToken": "<your-github-token>".*`)
+
+	c.Assert(Main([]string{"convert-config", "xml"}, s.stdout, s.stderr), Equals, ExitUsage)
+}
@@ -0,0 +1,148 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+var (
+	ErrMissingServer    = errors.New("Missing server, use --server, DOCKERSHIP_SERVER or the login command")
+	ErrNotAuthenticated = errors.New("Not authenticated, use --token, DOCKERSHIP_TOKEN or the login command")
+)
+
+// SessionCookie is the name of the cookie holding the browser session
+const SessionCookie = "oauth2_token"
+
+// Client talks to the REST API of a dockership daemon using an API token or
+// a browser session cookie
+type Client struct {
+	Server  string
+	Token   string
+	Session string
+	client  *http.Client
+}
+
+func NewClient(c *Credentials) *Client {
+	return &Client{
+		Server:  strings.TrimRight(c.Server, "/"),
+		Token:   c.Token,
+		Session: c.Session,
+		client: &http.Client{
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return ErrNotAuthenticated
+			},
+		},
+	}
+}
+
+// Get requests the path and decodes the JSON response into v, the failed
+// operations, answered with a 500 and its result, are decoded as well
+func (c *Client) Get(path string, query url.Values, v interface{}) error {
+	res, err := c.do("GET", path, query, "application/json")
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+	raw, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode == http.StatusInternalServerError {
+		var e struct{ Error string }
+		if json.Unmarshal(raw, &e) == nil && e.Error != "" {
+			return errors.New(e.Error)
+		}
+	}
+
+	return json.Unmarshal(raw, v)
+}
+
+// Stream requests the path as JSON lines, calling f with every line
+func (c *Client) Stream(path string, query url.Values, f func(line []byte) error) error {
+	res, err := c.do("GET", path, query, "application/x-ndjson")
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+	r := bufio.NewReader(res.Body)
+	for {
+		line, err := r.ReadBytes('\n')
+		if len(strings.TrimSpace(string(line))) != 0 {
+			if err := f(line); err != nil {
+				return err
+			}
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (c *Client) do(method, path string, query url.Values, accept string) (*http.Response, error) {
+	if c.Server == "" {
+		return nil, ErrMissingServer
+	}
+
+	u := c.Server + path
+	if len(query) != 0 {
+		u += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequest(method, u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", accept)
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	} else if c.Session != "" {
+		req.AddCookie(&http.Cookie{Name: SessionCookie, Value: c.Session})
+	}
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		if uerr, ok := err.(*url.Error); ok && uerr.Err == ErrNotAuthenticated {
+			return nil, ErrNotAuthenticated
+		}
+
+		return nil, err
+	}
+
+	if res.StatusCode == http.StatusUnauthorized {
+		res.Body.Close()
+		return nil, ErrNotAuthenticated
+	}
+
+	// failed operations are answered with a 500 and the result as body
+	if res.StatusCode >= 400 && res.StatusCode != http.StatusInternalServerError {
+		defer res.Body.Close()
+		return nil, decodeError(res)
+	}
+
+	return res, nil
+}
+
+func decodeError(res *http.Response) error {
+	var e struct{ Error string }
+	if err := json.NewDecoder(res.Body).Decode(&e); err != nil || e.Error == "" {
+		return fmt.Errorf("Unexpected response %q", res.Status)
+	}
+
+	return errors.New(e.Error)
+}
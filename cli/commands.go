@@ -0,0 +1,363 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mcuadros/dockership/core"
+	"github.com/mcuadros/dockership/store"
+)
+
+func runLogin(c *Client, o *options, args []string) int {
+	creds := &Credentials{Server: c.Server, Token: c.Token, Session: o.session}
+	if o.session != "" {
+		creds.Token = ""
+	}
+
+	if creds.Server == "" {
+		return fail(o, ErrMissingServer)
+	}
+
+	if creds.Token == "" && creds.Session == "" {
+		return fail(o, ErrNotAuthenticated)
+	}
+
+	var user map[string]interface{}
+	if err := NewClient(creds).Get("/rest/user", nil, &user); err != nil {
+		return fail(o, err)
+	}
+
+	if err := creds.Save(); err != nil {
+		return fail(o, err)
+	}
+
+	fmt.Fprintf(o.stdout, "Logged in %s as %v\n", creds.Server, user["Login"])
+	return ExitOK
+}
+
+func runProjects(c *Client, o *options, args []string) int {
+	var projects map[string]*core.Project
+	if err := c.Get("/rest/projects", nil, &projects); err != nil {
+		return fail(o, err)
+	}
+
+	if o.json {
+		printJSON(o, projects)
+		return ExitOK
+	}
+
+	var names []string
+	for name := range projects {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	t := newTable(o, "PROJECT", "REPOSITORY", "ENVIRONMENTS")
+	for _, name := range names {
+		p := projects[name]
+		fmt.Fprintf(t, "%s\t%s\t%s\n", name, p.Repository, strings.Join(p.EnvironmentNames, ", "))
+	}
+
+	t.Flush()
+	return ExitOK
+}
+
+func runStatus(c *Client, o *options, args []string) int {
+	path := "/rest/status"
+	if len(args) == 1 {
+		path += "/" + url.QueryEscape(args[0])
+	}
+
+	var result map[string]*statusResult
+	if err := c.Get(path, nil, &result); err != nil {
+		return fail(o, err)
+	}
+
+	return printStatus(o, result)
+}
+
+func printStatus(o *options, result map[string]*statusResult) int {
+	code := ExitOK
+	for _, r := range result {
+		if len(r.Error) != 0 {
+			code = ExitFailure
+		}
+	}
+
+	if o.json {
+		printJSON(o, result)
+		return code
+	}
+
+	var names []string
+	for name := range result {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	t := newTable(o, "PROJECT", "ENVIRONMENT", "RUNNING", "LAST", "STATUS")
+	for _, name := range names {
+		r := result[name]
+		if len(r.Error) != 0 {
+			fmt.Fprintf(t, "%s\t\t\t\terror\n", name)
+			continue
+		}
+
+		var envs []string
+		for env := range r.Status {
+			envs = append(envs, env)
+		}
+
+		sort.Strings(envs)
+		for _, env := range envs {
+			s := r.Status[env]
+			if s.ProjectStatus == nil {
+				s.ProjectStatus = &core.ProjectStatus{}
+			}
+
+			fmt.Fprintf(t, "%s\t%s\t%s\t%s\t%s\n",
+				name, env, getRunningRevisions(s), s.LastRevisionLabel, getStatusLabel(s),
+			)
+		}
+	}
+
+	t.Flush()
+	return code
+}
+
+func getRunningRevisions(s *statusRecord) string {
+	var revs []string
+	for _, c := range s.RunningContainers {
+		rev := c.Image.GetRevisionString()
+		found := false
+		for _, r := range revs {
+			found = found || r == rev
+		}
+
+		if !found {
+			revs = append(revs, rev)
+		}
+	}
+
+	if len(revs) == 0 {
+		return "-"
+	}
+
+	return strings.Join(revs, ", ")
+}
+
+func getStatusLabel(s *statusRecord) string {
+	switch {
+	case len(s.RunningContainers) == 0:
+		return "not running"
+	case s.Environment != nil && s.IsUpToDate():
+		return "up to date"
+	}
+
+	return "outdated"
+}
+
+func runContainers(c *Client, o *options, args []string) int {
+	var result []*containersRecord
+	err := c.Get("/rest/containers/"+url.QueryEscape(args[0]), nil, &result)
+	if err != nil {
+		return fail(o, err)
+	}
+
+	code := ExitOK
+	for _, r := range result {
+		if len(r.Error) != 0 {
+			code = ExitFailure
+		}
+	}
+
+	if o.json {
+		printJSON(o, result)
+		return code
+	}
+
+	t := newTable(o, "END-POINT", "CONTAINER", "IMAGE", "STATUS", "PORTS")
+	for _, r := range result {
+		if r.Container == nil {
+			continue
+		}
+
+		fmt.Fprintf(t, "%s\t%s\t%s\t%s\t%s\n",
+			r.Container.DockerEndPoint, r.Container.GetShortID(), r.Container.Image,
+			r.Container.Status, r.Container.GetPortsString(),
+		)
+	}
+
+	t.Flush()
+	return code
+}
+
+func runDeploy(c *Client, o *options, args []string) int {
+	path := fmt.Sprintf("/rest/deploy/%s/%s", url.QueryEscape(args[0]), url.QueryEscape(args[1]))
+	query := url.Values{"stream": []string{"ndjson"}}
+
+	var result *deployEvent
+	err := c.Stream(path, query, func(line []byte) error {
+		e := &deployEvent{}
+		if err := json.Unmarshal(line, e); err != nil {
+			return err
+		}
+
+		if e.Log == nil {
+			result = e
+			return nil
+		}
+
+		if o.json {
+			fmt.Fprintf(o.stdout, "%s", line)
+		} else {
+			fmt.Fprintf(o.stdout, "%s | %s\n", e.EndPoint, *e.Log)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return fail(o, err)
+	}
+
+	if result == nil {
+		return fail(o, fmt.Errorf("Deploy result not received"))
+	}
+
+	if o.json {
+		printJSON(o, result)
+	} else if result.Done {
+		fmt.Fprintf(o.stderr, "Deploy succeeded in %s\n", result.Elapsed)
+	} else {
+		fmt.Fprintf(o.stderr, "Deploy failed in %s with %d error(s)\n", result.Elapsed, len(result.Errors))
+	}
+
+	if !result.Done {
+		return ExitFailure
+	}
+
+	return ExitOK
+}
+
+func runRollback(c *Client, o *options, args []string) int {
+	path := fmt.Sprintf("/rest/rollback/%s/%s", url.QueryEscape(args[0]), url.QueryEscape(args[1]))
+	query := url.Values{}
+	if o.revision != "" {
+		query.Set("revision", o.revision)
+	}
+
+	result := &rollbackResult{}
+	if err := c.Get(path, query, result); err != nil {
+		return fail(o, err)
+	}
+
+	if o.json {
+		printJSON(o, result)
+	} else if result.Done {
+		fmt.Fprintf(o.stdout, "Rolled back to %s in %s\n", result.Image, result.Elapsed)
+	} else {
+		fmt.Fprintf(o.stderr, "Rollback failed in %s with %d error(s)\n", result.Elapsed, len(result.Errors))
+	}
+
+	if !result.Done {
+		return ExitFailure
+	}
+
+	return ExitOK
+}
+
+func runLogs(c *Client, o *options, args []string) int {
+	path := fmt.Sprintf("/rest/logs/%s/%s", url.QueryEscape(args[0]), url.QueryEscape(args[1]))
+	query := url.Values{"tail": []string{o.tail}}
+	if o.follow {
+		query.Set("follow", "true")
+	}
+
+	if o.since != "" {
+		query.Set("since", o.since)
+	}
+
+	var result *logsEvent
+	err := c.Stream(path, query, func(line []byte) error {
+		e := &logsEvent{}
+		if err := json.Unmarshal(line, e); err != nil {
+			return err
+		}
+
+		if e.Stream == "" {
+			result = e
+			return nil
+		}
+
+		w := o.stdout
+		if e.Stream == "stderr" {
+			w = o.stderr
+		}
+
+		if o.json {
+			fmt.Fprintf(w, "%s", line)
+		} else {
+			fmt.Fprintf(w, "%s %s | %s\n", e.DockerEndPoint, e.Container, e.Line)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return fail(o, err)
+	}
+
+	if result == nil || !result.Done {
+		return ExitFailure
+	}
+
+	return ExitOK
+}
+
+func runHistory(c *Client, o *options, args []string) int {
+	query := url.Values{"limit": []string{strconv.Itoa(o.limit)}}
+	for k, v := range map[string]string{
+		"project": o.project, "environment": o.environment, "user": o.user,
+	} {
+		if v != "" {
+			query.Set(k, v)
+		}
+	}
+
+	var deploys []*store.Deploy
+	if err := c.Get("/rest/history", query, &deploys); err != nil {
+		return fail(o, err)
+	}
+
+	if o.json {
+		printJSON(o, deploys)
+		return ExitOK
+	}
+
+	t := newTable(o, "ID", "PROJECT", "ENVIRONMENT", "USER", "REVISION", "RESULT", "START", "DURATION")
+	for _, d := range deploys {
+		result := "failed"
+		if d.Done {
+			result = "done"
+		}
+
+		revision := "-"
+		if len(d.Revision) != 0 {
+			revision = d.Revision.GetShort()
+		}
+
+		fmt.Fprintf(t, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			d.ID, d.Project, d.Environment, d.User, revision, result,
+			d.Start.Format(time.RFC3339), d.End.Sub(d.Start),
+		)
+	}
+
+	t.Flush()
+	return ExitOK
+}
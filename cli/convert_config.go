@@ -0,0 +1,29 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/mcuadros/dockership/config"
+)
+
+func runConvertConfig(c *Client, o *options, args []string) int {
+	format, err := config.ParseFormat(args[0])
+	if err != nil {
+		fmt.Fprintf(o.stderr, "Error: %s\n", err)
+		return ExitUsage
+	}
+
+	if config.GetFormat(o.config) != config.FormatINI {
+		fmt.Fprintf(o.stderr, "Error: %q is not an INI config file\n", o.config)
+		return ExitFailure
+	}
+
+	raw, err := config.ConvertFile(o.config, format)
+	if err != nil {
+		fmt.Fprintf(o.stderr, "Error: %s\n", err)
+		return ExitFailure
+	}
+
+	o.stdout.Write(raw)
+	return ExitOK
+}
@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// Credentials are the server and the API token or the browser session used by
+// the client, they are stored by the login command
+type Credentials struct {
+	Server  string
+	Token   string `json:",omitempty"`
+	Session string `json:",omitempty"`
+}
+
+func getCredentialsFile() string {
+	return filepath.Join(os.Getenv("HOME"), ".dockership.json")
+}
+
+// LoadCredentials reads the stored credentials, overridden by the
+// DOCKERSHIP_SERVER and DOCKERSHIP_TOKEN environment variables
+func LoadCredentials() *Credentials {
+	c := &Credentials{}
+	if raw, err := ioutil.ReadFile(getCredentialsFile()); err == nil {
+		json.Unmarshal(raw, c)
+	}
+
+	if server := os.Getenv("DOCKERSHIP_SERVER"); server != "" {
+		c.Server = server
+	}
+
+	if token := os.Getenv("DOCKERSHIP_TOKEN"); token != "" {
+		c.Token = token
+		c.Session = ""
+	}
+
+	return c
+}
+
+func (c *Credentials) Save() error {
+	raw, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(getCredentialsFile(), raw, 0600)
+}
@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/mcuadros/dockership/config"
+	"github.com/mcuadros/dockership/core"
+)
+
+// The local commands run straight from the config file, without a daemon,
+// the core events are written to stderr
+
+func loadLocalProject(o *options, name string) (*core.Project, error) {
+	subscribeEvents(o.stderr, o.verbose)
+
+	var c config.Config
+	if err := c.LoadFile(o.config); err != nil {
+		return nil, err
+	}
+
+	p, ok := c.Projects[name]
+	if !ok {
+		return nil, fmt.Errorf("Project %q not found", name)
+	}
+
+	return p, nil
+}
+
+func runLocalDeploy(c *Client, o *options, args []string) int {
+	p, err := loadLocalProject(o, args[0])
+	if err != nil {
+		return fail(o, err)
+	}
+
+	output := o.stdout
+	if o.json {
+		output = o.stderr
+	}
+
+	report := p.DeployWithReport(args[1], output, true)
+	if o.json {
+		printJSON(o, report)
+	}
+
+	return printErrors(o, report.Errors)
+}
+
+func runLocalStatus(c *Client, o *options, args []string) int {
+	p, err := loadLocalProject(o, args[0])
+	if err != nil {
+		return fail(o, err)
+	}
+
+	r := &statusResult{Project: p, Status: make(map[string]*statusRecord, 0)}
+	for name, e := range p.Environments {
+		if len(args) == 2 && args[1] != name {
+			continue
+		}
+
+		s, errs := p.StatusByEnvironment(e)
+		for _, err := range errs {
+			r.Error = append(r.Error, err.Error())
+		}
+
+		if len(errs) == 0 {
+			r.Status[name] = &statusRecord{s.LastRevision.Get(), s}
+		}
+	}
+
+	if len(args) == 2 && len(r.Status) == 0 && len(r.Error) == 0 {
+		return fail(o, fmt.Errorf("Environment %q not found", args[1]))
+	}
+
+	for _, err := range r.Error {
+		fmt.Fprintf(o.stderr, "Error: %s\n", err)
+	}
+
+	return printStatus(o, map[string]*statusResult{p.Name: r})
+}
+
+func runLocalClean(c *Client, o *options, args []string) int {
+	p, err := loadLocalProject(o, args[0])
+	if err != nil {
+		return fail(o, err)
+	}
+
+	return printErrors(o, p.Clean(args[1]))
+}
+
+func printErrors(o *options, errs []error) int {
+	for _, err := range errs {
+		fmt.Fprintf(o.stderr, "Error: %s\n", err)
+	}
+
+	if len(errs) != 0 {
+		return ExitFailure
+	}
+
+	return ExitOK
+}
@@ -0,0 +1,40 @@
+package cli
+
+import (
+	"io"
+
+	"github.com/mcuadros/dockership/core"
+
+	"gopkg.in/inconshreveable/log15.v2"
+)
+
+// subscribeEvents writes the core events to w, the debug ones only if verbose
+func subscribeEvents(w io.Writer, verbose bool) {
+	lvl := log15.LvlInfo
+	if verbose {
+		lvl = log15.LvlDebug
+	}
+
+	logger := log15.New()
+	logger.SetHandler(log15.LvlFilterHandler(lvl, log15.StreamHandler(w, log15.TerminalFormat())))
+
+	core.Events.Subscribe(core.EventInfo, &core.Subscriber{func(ctx ...interface{}) {
+		logger.Info(ctx[0].(string), ctx[1:]...)
+	}})
+
+	core.Events.Subscribe(core.EventDebug, &core.Subscriber{func(ctx ...interface{}) {
+		logger.Debug(ctx[0].(string), ctx[1:]...)
+	}})
+
+	core.Events.Subscribe(core.EventWarning, &core.Subscriber{func(ctx ...interface{}) {
+		logger.Warn(ctx[0].(string), ctx[1:]...)
+	}})
+
+	core.Events.Subscribe(core.EventError, &core.Subscriber{func(ctx ...interface{}) {
+		logger.Error(ctx[0].(string), ctx[1:]...)
+	}})
+
+	core.Events.Subscribe(core.EventCritical, &core.Subscriber{func(ctx ...interface{}) {
+		logger.Crit(ctx[0].(string), ctx[1:]...)
+	}})
+}
@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"time"
+
+	"github.com/mcuadros/dockership/core"
+)
+
+// The types below are the JSON responses of the daemon, as the http package
+// types, with the errors as they are serialized
+
+// statusResult is a http.StatusResult
+type statusResult struct {
+	Project *core.Project
+	Status  map[string]*statusRecord
+	Error   []interface{}
+}
+
+// statusRecord is a http.StatusRecord
+type statusRecord struct {
+	LastRevisionLabel string
+	*core.ProjectStatus
+}
+
+// containersRecord is a http.ContainersRecord
+type containersRecord struct {
+	Project   *core.Project
+	Container *core.Container
+	Error     []interface{}
+}
+
+// deployEvent is a http.DeployLine or, the last one, a http.DeployResult
+type deployEvent struct {
+	EndPoint string  `json:"endpoint"`
+	Log      *string `json:"log"`
+	Done     bool
+	Elapsed  time.Duration
+	Errors   []interface{} `json:",omitempty"`
+}
+
+// rollbackResult is a http.RollbackResult
+type rollbackResult struct {
+	Done    bool
+	Elapsed time.Duration
+	Image   core.ImageID
+	Errors  []interface{} `json:",omitempty"`
+}
+
+// logsEvent is a core.LogLine or, the last one, a http.LogsResult
+type logsEvent struct {
+	core.LogLine
+	Done    bool
+	Elapsed time.Duration
+	Errors  []interface{} `json:",omitempty"`
+}
@@ -75,6 +75,16 @@ module.exports = [
 
     });
 
+    socket.addHandler('deploy-environment', function (result) {
+      $scope.loadStatus();
+    });
+
+    socket.addHandler('container-event', function (result) {
+      if (result.Status.indexOf('health_status') != 0) {
+        $scope.loadStatus();
+      }
+    });
+
     socket.addHandler('projects', function (result) {
       $scope.projects = result;
 
@@ -85,6 +95,18 @@ module.exports = [
       $scope.loadStatus();
     });
 
+    $scope.driftSummary = function (drift) {
+      var lines = [];
+      angular.forEach(drift, function (container) {
+        angular.forEach(container.Diffs, function (diff) {
+          lines.push(container.DockerEndPoint + ' ' + container.Container + ': '
+            + diff.Field + ' is "' + diff.Found + '", expected "' + diff.Expected + '"');
+        });
+      });
+
+      return lines.join('\n');
+    };
+
     $scope.openContainers = function (project) {
       $scope.processing = true;
       socket.getContainers(project);
@@ -20,6 +20,30 @@ module.exports = [
       }))
     };
 
+    socket.doEnvironmentDeploy = function (environment, projects) {
+      socket.send(angular.toJson({
+        event: 'deploy-environment',
+        request: {
+          environment: environment.Name,
+          projects: (projects || []).map(function (project) {
+            return project.Name;
+          }).join(',')
+        }
+      }))
+    };
+
+    socket.runTask = function (project, environment, command, endpoint) {
+      socket.send(angular.toJson({
+        event: 'task',
+        request: {
+          project: project.Name,
+          environment: environment.Name,
+          command: command,
+          endpoint: endpoint || ''
+        }
+      }))
+    };
+
     socket.getContainers = function (project) {
       socket.send(angular.toJson({
         event: 'containers',
@@ -27,6 +51,30 @@ module.exports = [
       }))
     };
 
+    socket.getLogs = function (project, environment, options) {
+      options = options || {};
+      socket.send(angular.toJson({
+        event: 'containers/logs',
+        request: {
+          project: project.Name,
+          environment: environment.Name,
+          follow: options.follow ? 'true' : 'false',
+          tail: options.tail ? String(options.tail) : 'all',
+          since: options.since ? String(options.since) : ''
+        }
+      }))
+    };
+
+    socket.subscribeStats = function (interval, project) {
+      socket.send(angular.toJson({
+        event: 'stats',
+        request: {
+          project: project ? project.Name : '',
+          interval: String(interval === undefined ? 5 : interval)
+        }
+      }))
+    };
+
     socket.getStatus = function (project) {
       socket.send(angular.toJson({
         event: 'status',
@@ -36,7 +84,9 @@ module.exports = [
 
     socket.setHandler('message', function (e) {
       data = angular.fromJson(e.data);
-      socket._handlers[data.event](data.result);
+      if (socket._handlers[data.event] != undefined) {
+        socket._handlers[data.event](data.result);
+      }
     });
 
 
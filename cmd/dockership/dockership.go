@@ -0,0 +1,12 @@
+package main
+
+import (
+	"github.com/mcuadros/dockership/cli"
+)
+
+var version string
+var build string
+
+func main() {
+	cli.Start(version, build)
+}
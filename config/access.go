@@ -0,0 +1,138 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type Role int
+
+const (
+	RoleNone Role = iota
+	RoleViewer
+	RoleDeployer
+	RoleAdmin
+)
+
+var roleNames = map[Role]string{
+	RoleNone:     "none",
+	RoleViewer:   "viewer",
+	RoleDeployer: "deployer",
+	RoleAdmin:    "admin",
+}
+
+func ParseRole(name string) (Role, error) {
+	for r, n := range roleNames {
+		if r != RoleNone && strings.EqualFold(n, name) {
+			return r, nil
+		}
+	}
+
+	return RoleNone, fmt.Errorf("Unknown role %q, expected viewer, deployer or admin", name)
+}
+
+func (r Role) String() string {
+	return roleNames[r]
+}
+
+func (r Role) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// Grant gives a role to the users and Github teams, as org/team-slug, at the
+// given projects and environments, all of them if none is given
+type Grant struct {
+	Role         string
+	Users        []string `gcfg:"User"`
+	Teams        []string `gcfg:"Team"`
+	Projects     []string `gcfg:"Project"`
+	Environments []string `gcfg:"Environment"`
+	parsedRole   Role
+}
+
+func (g *Grant) matchUser(user string, teams []string) bool {
+	if containsFold(g.Users, user) {
+		return true
+	}
+
+	for _, t := range teams {
+		if containsFold(g.Teams, t) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (g *Grant) matchScope(project, environment string) bool {
+	return matchAll(g.Projects, project) && matchAll(g.Environments, environment)
+}
+
+// GetRole returns the higher role granted to the user, or any of his teams,
+// at the project and environment. If no grant is defined every user is admin.
+func (c *Config) GetRole(user string, teams []string, project, environment string) Role {
+	if len(c.Grants) == 0 {
+		return RoleAdmin
+	}
+
+	role := RoleNone
+	for _, g := range c.Grants {
+		if g.parsedRole > role && g.matchUser(user, teams) && g.matchScope(project, environment) {
+			role = g.parsedRole
+		}
+	}
+
+	return role
+}
+
+// ValidateGrants checks the roles, projects and environments of the grants
+func (c *Config) ValidateGrants() error {
+	for name, g := range c.Grants {
+		section := sectionName("Grant", name)
+		file := c.getSource(section)
+		r, err := ParseRole(g.Role)
+		if err != nil {
+			return errors.New(withFile(file, fmt.Sprintf("%s: %s", section, err)))
+		}
+
+		g.parsedRole = r
+		for _, p := range g.Projects {
+			if _, ok := c.Projects[p]; !ok && p != "*" {
+				return errors.New(withFile(file, fmt.Sprintf("%s: unknown project %q", section, p)))
+			}
+		}
+
+		for _, e := range g.Environments {
+			if _, ok := c.Environments[e]; !ok && e != "*" {
+				return errors.New(withFile(file, fmt.Sprintf("%s: unknown environment %q", section, e)))
+			}
+		}
+	}
+
+	return nil
+}
+
+func matchAll(list []string, value string) bool {
+	if len(list) == 0 {
+		return true
+	}
+
+	for _, v := range list {
+		if v == "*" || v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsFold(list []string, value string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+
+	return false
+}
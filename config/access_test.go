@@ -0,0 +1,77 @@
+package config
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+const grantsConfig = `
+[Environment "testing"]
+DockerEndPoint = http://testing:4243
+
+[Environment "live"]
+DockerEndPoint = http://live:4243
+
+[Project "a"]
+Repository = git@github.com:my-company/a.git
+Environment = testing
+Environment = live
+
+[Grant "everyone"]
+Role = deployer
+Team = my-company/developers
+Environment = testing
+
+[Grant "ops"]
+Role = admin
+Team = my-company/ops
+User = foo
+
+[Grant "viewers"]
+Role = viewer
+Team = my-company/developers
+`
+
+func (s *ConfigSuite) TestConfig_GetRole(c *C) {
+	var config Config
+	err := config.LoadFile(writeConfigFile(grantsConfig))
+	c.Assert(err, IsNil)
+
+	dev := []string{"my-company/developers"}
+	c.Assert(config.GetRole("bar", dev, "a", "testing"), Equals, RoleDeployer)
+	c.Assert(config.GetRole("bar", dev, "a", "live"), Equals, RoleViewer)
+	c.Assert(config.GetRole("bar", []string{"My-Company/Ops"}, "a", "live"), Equals, RoleAdmin)
+	c.Assert(config.GetRole("FOO", nil, "a", "live"), Equals, RoleAdmin)
+	c.Assert(config.GetRole("qux", nil, "a", "live"), Equals, RoleNone)
+}
+
+func (s *ConfigSuite) TestConfig_GetRoleWithoutGrants(c *C) {
+	var config Config
+	c.Assert(config.GetRole("qux", nil, "a", "live"), Equals, RoleAdmin)
+}
+
+func (s *ConfigSuite) TestConfig_LoadFileInvalidGrant(c *C) {
+	var config Config
+	err := config.LoadFile(writeConfigFile(`
+[Grant "foo"]
+Role = root
+User = foo
+`))
+
+	c.Assert(err, ErrorMatches, `/.*: Grant "foo": Unknown role "root".*`)
+
+	var other Config
+	err = other.LoadFile(writeConfigFile(`
+[Grant "foo"]
+Role = admin
+Project = qux
+`))
+
+	c.Assert(err, ErrorMatches, `/.*: Grant "foo": unknown project "qux"`)
+}
+
+func (s *ConfigSuite) TestRole_String(c *C) {
+	r, err := ParseRole("Deployer")
+	c.Assert(err, IsNil)
+	c.Assert(r, Equals, RoleDeployer)
+	c.Assert(r.String(), Equals, "deployer")
+}
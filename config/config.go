@@ -1,9 +1,10 @@
 package config
 
 import (
+	"strings"
+
 	"github.com/mcuadros/dockership/core"
 
-	"gopkg.in/gcfg.v1"
 	"github.com/mcuadros/go-defaults"
 )
 
@@ -11,36 +12,85 @@ const DefaultConfig = "/etc/dockership/dockership.conf"
 
 type Config struct {
 	Global struct {
-		UseShortRevisions bool `default:"true"`
-		GithubToken       string
+		UseShortRevisions bool     `default:"true"`
+		GithubToken       string   `json:"-"`
 		EtcdServers       []string `gcfg:"EtcdServer"`
+		Database          string   `default:"/var/lib/dockership/dockership.db"`
+		Reconcile         bool
+		ReconcileInterval int      `default:"60"`
+		Includes          []string `gcfg:"Include"`
+		EtcdPrefix        string
 	}
 	HTTP struct {
 		Listen             string `default:":8080"`
 		GithubID           string
-		GithubSecret       string
+		GithubSecret       string `json:"-"`
 		GithubOrganization string
 		GithubUsers        []string `gcfg:"GithubUser"`
 		GithubRedirectURL  string
+		ExecUsers          []string `gcfg:"ExecUser"`
+		TrustedProxies     []string `gcfg:"TrustedProxy"`
+	}
+	Audit struct {
+		File   string
+		Syslog bool
 	}
-	Projects     map[string]*core.Project     `gcfg:"Project"`
-	Environments map[string]*core.Environment `gcfg:"Environment"`
+	Templates           map[string]*core.Project     `gcfg:"Template"`
+	Projects            map[string]*core.Project     `gcfg:"Project"`
+	ProjectEnvironments map[string]*core.Project     `gcfg:"ProjectEnvironment"`
+	Environments        map[string]*core.Environment `gcfg:"Environment"`
+	Grants              map[string]*Grant            `gcfg:"Grant"`
+	sources             map[string]string
+	resolved            map[*core.Project]bool
 }
 
 func (c *Config) LoadFile(filename string) error {
-	err := gcfg.ReadFileInto(c, filename)
+	if err := c.ReadFile(filename); err != nil {
+		return err
+	}
+
+	if err := c.ValidateLinks(); err != nil {
+		return err
+	}
+
+	return c.ValidateGrants()
+}
+
+// ReadFile reads and loads the config file without validating it, Validate
+// should be used to find the problems of the config. The format is chosen by
+// the file extension, see GetFormat. If filename is a directory every file on
+// it is read.
+func (c *Config) ReadFile(filename string) error {
+	err := c.readFiles(filename)
 	if err != nil {
 		return err
 	}
 
+	if err := c.readEtcd(); err != nil {
+		return err
+	}
+
 	defaults.SetDefaults(c)
-	c.LoadProjects()
+	return c.load()
+}
+
+func (c *Config) load() error {
+	if err := c.LoadProjects(); err != nil {
+		return err
+	}
+
 	c.LoadEnvironments()
 	c.LinkProjectsAndEnviroments()
 	return nil
 }
 
-func (c *Config) LoadProjects() {
+// LoadProjects sets the name, defaults and global settings of every project,
+// the templates are resolved before the defaults are applied
+func (c *Config) LoadProjects() error {
+	if err := c.resolveTemplates(); err != nil {
+		return err
+	}
+
 	for name, p := range c.Projects {
 		p.Name = name
 		defaults.SetDefaults(p)
@@ -51,7 +101,28 @@ func (c *Config) LoadProjects() {
 		p.UseShortRevisions = c.Global.UseShortRevisions
 		p.LinkedBy = make([]*core.Project, 0)
 		p.TaskStatus = core.TaskStatus{}
+		p.Overrides = make(map[string]*core.Project, 0)
+	}
+
+	for name, o := range c.ProjectEnvironments {
+		project, env := SplitProjectEnvironment(name)
+		if p, ok := c.Projects[project]; ok {
+			p.Overrides[env] = o
+		}
+	}
+
+	return nil
+}
+
+// SplitProjectEnvironment splits the name of a ProjectEnvironment section,
+// "project.environment", the environment being the part after the last dot
+func SplitProjectEnvironment(name string) (project, environment string) {
+	i := strings.LastIndex(name, ".")
+	if i == -1 {
+		return name, ""
 	}
+
+	return name[:i], name[i+1:]
 }
 
 func (c *Config) LoadEnvironments() {
@@ -68,7 +139,9 @@ func (c *Config) LinkProjectsAndEnviroments() {
 	for _, p := range c.Projects {
 		p.Environments = make(map[string]*core.Environment, 0)
 		for _, e := range p.EnvironmentNames {
-			p.Environments[e] = c.mustGetEnvironment(p, e)
+			if env := c.mustGetEnvironment(p, e); env != nil {
+				p.Environments[e] = env
+			}
 		}
 
 		p.Links = make(map[string]*core.Link, 0)
@@ -87,6 +160,17 @@ func (c *Config) LinkProjectsAndEnviroments() {
 	}
 }
 
+// ValidateLinks checks the links between the projects are not cyclic
+func (c *Config) ValidateLinks() error {
+	projects := make([]*core.Project, 0, len(c.Projects))
+	for _, p := range c.Projects {
+		projects = append(projects, p)
+	}
+
+	_, err := core.SortByLinks(projects)
+	return err
+}
+
 func (c *Config) mustGetEnvironment(p *core.Project, name string) *core.Environment {
 	if e, ok := c.Environments[name]; ok {
 		defaults.SetDefaults(e)
@@ -1,6 +1,9 @@
 package config
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	. "gopkg.in/check.v1"
@@ -55,3 +58,141 @@ func (s *ConfigSuite) TestConfig_LoadFile(c *C) {
 	c.Assert(projectB.Links["mysql"].Container, Equals, "mysql")
 
 }
+
+func (s *ConfigSuite) TestConfig_LoadFileCyclicLinks(c *C) {
+	var config Config
+	err := config.LoadFile(writeConfigFile(`
+[Project "a"]
+Repository = git@github.com:my-company/a.git
+Link = b:b
+
+[Project "b"]
+Repository = git@github.com:my-company/b.git
+Link = a:a
+`))
+
+	c.Assert(err, ErrorMatches, "Cyclic links between projects: a -> b -> a")
+}
+
+func (s *ConfigSuite) TestConfig_LoadFileProjectEnvironment(c *C) {
+	var config Config
+	err := config.LoadFile(writeConfigFile(`
+[Project "a"]
+Repository = git@github.com:my-company/a.git
+Environment = live
+Environment = testing
+Volume = /data:/data
+
+[ProjectEnvironment "a.testing"]
+Repository = git@github.com:my-company/a.git!develop
+Volume = /tmp:/data
+History = 1
+
+[Environment "live"]
+DockerEndPoint = http://live:4243
+
+[Environment "testing"]
+DockerEndPoint = http://testing:4243
+`))
+
+	c.Assert(err, IsNil)
+
+	p := config.Projects["a"]
+	c.Assert(p.Overrides, HasLen, 1)
+	c.Assert(p.ForEnvironment("live"), Equals, p)
+
+	t := p.ForEnvironment("testing")
+	c.Assert(t.Repository.Info().Branch, Equals, "develop")
+	c.Assert(t.Binds, DeepEquals, []string{"/tmp:/data"})
+	c.Assert(t.History, Equals, 1)
+	c.Assert(t.Dockerfile, Equals, "Dockerfile")
+	c.Assert(config.Validate().Errors(), HasLen, 0)
+}
+
+func writeConfigFile(content string) string {
+	f, err := ioutil.TempFile("", "dockership")
+	if err != nil {
+		panic(err)
+	}
+
+	defer f.Close()
+	if _, err := f.WriteString(content); err != nil {
+		panic(err)
+	}
+
+	return f.Name()
+}
+
+func (s *ConfigSuite) TestConfig_LoadFileUnknownEnvironment(c *C) {
+	var config Config
+	err := config.LoadFile(writeConfigFile(`
+[Project "a"]
+Repository = git@github.com:my-company/a.git
+Environment = live
+`))
+
+	c.Assert(err, IsNil)
+	c.Assert(config.Projects["a"].Environments, HasLen, 0)
+}
+
+func (s *ConfigSuite) TestConfig_LoadFileInclude(c *C) {
+	dir := c.MkDir()
+	writeFile(dir+"/conf.d/a.conf", `
+[Project "a"]
+Repository = git@github.com:my-company/a.git
+Environment = live
+`)
+
+	writeFile(dir+"/conf.d/b.json", `{"Project": {"b": {"Repository": "git@github.com:my-company/b.git", "Link": "a"}}}`)
+	writeFile(dir+"/dockership.conf", `
+[Global]
+Include = conf.d/*
+
+[Environment "live"]
+DockerEndPoint = http://live:4243
+`)
+
+	var config Config
+	c.Assert(config.LoadFile(dir+"/dockership.conf"), IsNil)
+	c.Assert(config.Projects, HasLen, 2)
+	c.Assert(config.Projects["a"].Environments["live"], NotNil)
+	c.Assert(config.Projects["b"].Links["a"].Project, Equals, config.Projects["a"])
+
+	writeFile(dir+"/conf.d/c.conf", `
+[Project "a"]
+Repository = git@github.com:my-company/other.git
+`)
+
+	var other Config
+	err := other.LoadFile(dir + "/dockership.conf")
+	c.Assert(err, ErrorMatches, `.*/conf.d/c.conf: Project "a" already defined at .*/conf.d/a.conf`)
+}
+
+func (s *ConfigSuite) TestConfig_LoadFileDirectory(c *C) {
+	dir := c.MkDir()
+	writeFile(dir+"/global.conf", "[Global]\nGithubToken = foo\n")
+	writeFile(dir+"/project.conf", "[Project \"a\"]\nRepository = git@github.com:my-company/a.git\n")
+
+	var config Config
+	c.Assert(config.LoadFile(dir), IsNil)
+	c.Assert(config.Projects["a"].GithubToken, Equals, "foo")
+
+	problems := config.Validate()
+	c.Assert(problems, HasLen, 1)
+	c.Assert(problems[0].File, Equals, dir+"/project.conf")
+
+	writeFile(dir+"/other.conf", "[Global]\nGithubToken = bar\n")
+
+	var other Config
+	c.Assert(other.LoadFile(dir), ErrorMatches, `.*/other.conf: the Global, HTTP and Audit sections are already defined at .*/global.conf`)
+}
+
+func writeFile(filename, content string) {
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		panic(err)
+	}
+
+	if err := ioutil.WriteFile(filename, []byte(content), 0644); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,270 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/mcuadros/dockership/core"
+
+	"gopkg.in/gcfg.v1"
+)
+
+// The projects and environments can be defined at etcd, under the EtcdPrefix,
+// as JSON objects with the same keys than the config file:
+//
+//   <prefix>/projects/<name>      {"Repository": "...", "Environment": ["live"]}
+//   <prefix>/environments/<name>  {"DockerEndPoint": ["tcp://..."]}
+
+const (
+	DefinitionProject     = "projects"
+	DefinitionEnvironment = "environments"
+
+	etcdSource = "etcd:"
+)
+
+var (
+	ErrEtcdDisabled        = errors.New("EtcdPrefix is not configured")
+	ErrMissingEtcdServers  = errors.New("EtcdPrefix requires at least one EtcdServer")
+	ErrDefinedAtFile       = errors.New("Defined at a config file, only the ones defined at etcd can be changed")
+	ErrDefinitionNotFound  = errors.New("Definition not found at etcd")
+	ErrUnknownDefinition   = errors.New("Unknown definition kind, expected projects or environments")
+	ErrInvalidDefinitionID = errors.New("Invalid definition name")
+)
+
+var definitionSections = map[string]string{
+	DefinitionProject:     "Project",
+	DefinitionEnvironment: "Environment",
+}
+
+// readEtcd reads the project and environment definitions stored at etcd, a
+// name already defined at a config file is an error
+func (c *Config) readEtcd() error {
+	if c.Global.EtcdPrefix == "" {
+		return nil
+	}
+
+	e, err := c.getEtcd()
+	if err != nil {
+		return err
+	}
+
+	for _, kind := range []string{DefinitionProject, DefinitionEnvironment} {
+		values, err := e.List(path.Join(c.Global.EtcdPrefix, kind))
+		if err != nil {
+			return err
+		}
+
+		for _, key := range sortedKeys(values) {
+			part, err := parseDefinition(kind, path.Base(key), []byte(values[key]))
+			if err != nil {
+				return fmt.Errorf("%s%s: %s", etcdSource, key, err)
+			}
+
+			if err := c.merge(etcdSource+key, part); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// parseDefinition reads the JSON definition of a project or environment
+func parseDefinition(kind, name string, raw []byte) (*Config, error) {
+	section, ok := definitionSections[kind]
+	if !ok {
+		return nil, ErrUnknownDefinition
+	}
+
+	if name == "" || strings.ContainsAny(name, "/\"") {
+		return nil, ErrInvalidDefinitionID
+	}
+
+	var def map[string]interface{}
+	if err := json.Unmarshal(raw, &def); err != nil {
+		return nil, err
+	}
+
+	if err := checkDefinitionKeys(section, def); err != nil {
+		return nil, err
+	}
+
+	ini, err := treeToINI(map[string]interface{}{
+		section: map[string]interface{}{name: def},
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	part := &Config{}
+	if err := gcfg.ReadStringInto(part, ini); err != nil {
+		return nil, err
+	}
+
+	if !part.isDefinition(kind, name) {
+		return nil, fmt.Errorf("Invalid definition, only the %s section is allowed", sectionName(section, name))
+	}
+
+	return part, nil
+}
+
+// checkDefinitionKeys checks every key of the definition is a variable of the
+// section, any other key could inject arbitrary sections at the generated INI
+func checkDefinitionKeys(section string, def map[string]interface{}) error {
+	t, _ := fieldType(reflect.TypeOf(Config{}), section)
+	t = t.Elem().Elem()
+	for _, key := range sortedKeys(def) {
+		if _, ok := fieldType(t, key); !ok || !isVariableName(key) {
+			return fmt.Errorf("Unknown variable %q", key)
+		}
+	}
+
+	return nil
+}
+
+func isVariableName(key string) bool {
+	for _, r := range key {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_' {
+			return false
+		}
+	}
+
+	return key != ""
+}
+
+// isDefinition returns true if the config contains only the given project or
+// environment
+func (c *Config) isDefinition(kind, name string) bool {
+	if c.hasSettings() || len(c.Templates)+len(c.ProjectEnvironments)+len(c.Grants) != 0 {
+		return false
+	}
+
+	switch kind {
+	case DefinitionProject:
+		_, ok := c.Projects[name]
+		return ok && len(c.Projects) == 1 && len(c.Environments) == 0
+	case DefinitionEnvironment:
+		_, ok := c.Environments[name]
+		return ok && len(c.Environments) == 1 && len(c.Projects) == 0
+	}
+
+	return false
+}
+
+// SetDefinition adds or replaces, at the loaded config, the definition of a
+// project or environment stored at etcd, Validate should be used before
+// storing it with PutDefinition
+func (c *Config) SetDefinition(kind, name string, raw []byte) error {
+	if c.Global.EtcdPrefix == "" {
+		return ErrEtcdDisabled
+	}
+
+	part, err := parseDefinition(kind, name, raw)
+	if err != nil {
+		return err
+	}
+
+	section := sectionName(definitionSections[kind], name)
+	if source, ok := c.sources[section]; ok && !strings.HasPrefix(source, etcdSource) {
+		return ErrDefinedAtFile
+	}
+
+	c.removeDefinition(kind, name)
+	if err := c.merge(etcdSource+c.getDefinitionKey(kind, name), part); err != nil {
+		return err
+	}
+
+	return c.load()
+}
+
+// DeleteDefinition removes, from the loaded config, the definition of a
+// project or environment stored at etcd
+func (c *Config) DeleteDefinition(kind, name string) error {
+	if c.Global.EtcdPrefix == "" {
+		return ErrEtcdDisabled
+	}
+
+	section, ok := definitionSections[kind]
+	if !ok {
+		return ErrUnknownDefinition
+	}
+
+	source, ok := c.sources[sectionName(section, name)]
+	if !ok {
+		return ErrDefinitionNotFound
+	}
+
+	if !strings.HasPrefix(source, etcdSource) {
+		return ErrDefinedAtFile
+	}
+
+	c.removeDefinition(kind, name)
+	return c.load()
+}
+
+func (c *Config) removeDefinition(kind, name string) {
+	switch kind {
+	case DefinitionProject:
+		delete(c.resolved, c.Projects[name])
+		delete(c.Projects, name)
+	case DefinitionEnvironment:
+		delete(c.Environments, name)
+	}
+
+	delete(c.sources, sectionName(definitionSections[kind], name))
+}
+
+// PutDefinition stores the definition of a project or environment at etcd
+func (c *Config) PutDefinition(kind, name string, raw []byte) error {
+	e, err := c.getEtcd()
+	if err != nil {
+		return err
+	}
+
+	return e.Set(c.getDefinitionKey(kind, name), string(raw))
+}
+
+// RemoveDefinition deletes the definition of a project or environment from
+// etcd
+func (c *Config) RemoveDefinition(kind, name string) error {
+	e, err := c.getEtcd()
+	if err != nil {
+		return err
+	}
+
+	return e.Delete(c.getDefinitionKey(kind, name))
+}
+
+// WatchDefinitions calls fn every time a definition changes at etcd, until
+// stop is closed
+func (c *Config) WatchDefinitions(stop chan bool, fn func()) error {
+	e, err := c.getEtcd()
+	if err != nil {
+		return err
+	}
+
+	go e.Watch(c.Global.EtcdPrefix, stop, fn)
+	return nil
+}
+
+func (c *Config) getDefinitionKey(kind, name string) string {
+	return path.Join(c.Global.EtcdPrefix, kind, name)
+}
+
+func (c *Config) getEtcd() (*core.Etcd, error) {
+	if c.Global.EtcdPrefix == "" {
+		return nil, ErrEtcdDisabled
+	}
+
+	if len(c.Global.EtcdServers) == 0 {
+		return nil, ErrMissingEtcdServers
+	}
+
+	return core.NewEtcd(c.Global.EtcdServers)
+}
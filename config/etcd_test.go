@@ -0,0 +1,96 @@
+package config
+
+import (
+	. "gopkg.in/check.v1"
+	"gopkg.in/gcfg.v1"
+)
+
+func (s *ConfigSuite) TestConfig_SetDefinition(c *C) {
+	var config Config
+	c.Assert(config.LoadFile("../example/config.ini"), IsNil)
+	c.Assert(config.SetDefinition(DefinitionProject, "foo", []byte(`{}`)), Equals, ErrEtcdDisabled)
+
+	config.Global.EtcdPrefix = "/dockership"
+	err := config.SetDefinition(DefinitionEnvironment, "dev", []byte(`{"DockerEndPoint": ["tcp://dev:2375"]}`))
+	c.Assert(err, IsNil)
+
+	err = config.SetDefinition(DefinitionProject, "foo", []byte(`{
+		"Repository": "git@github.com:my-company/foo.git",
+		"Environment": ["dev", "live"],
+		"History": 2
+	}`))
+
+	c.Assert(err, IsNil)
+
+	p := config.Projects["foo"]
+	c.Assert(p.GithubToken, Equals, "<your-github-token>")
+	c.Assert(p.History, Equals, 2)
+	c.Assert(p.Dockerfile, Equals, "Dockerfile")
+	c.Assert(p.Environments["dev"].DockerEndPoints, DeepEquals, []string{"tcp://dev:2375"})
+	c.Assert(p.Environments["live"], Equals, config.Environments["live"])
+	c.Assert(config.Validate().Errors(), HasLen, 0)
+
+	err = config.SetDefinition(DefinitionProject, "foo", []byte(`{"Repository": "git@github.com:my-company/foo.git"}`))
+	c.Assert(err, IsNil)
+	c.Assert(config.Projects["foo"].EnvironmentNames, HasLen, 0)
+
+	problems := config.Validate()
+	c.Assert(problems, HasLen, 3)
+	c.Assert(problems[0].File, Equals, "etcd:/dockership/projects/foo")
+
+	err = config.SetDefinition(DefinitionProject, "project", []byte(`{}`))
+	c.Assert(err, Equals, ErrDefinedAtFile)
+
+	err = config.SetDefinition(DefinitionProject, "foo", []byte(`{"Port": {"foo": "bar"}}`))
+	c.Assert(err, ErrorMatches, "Project.foo.Port: unexpected value .*")
+}
+
+func (s *ConfigSuite) TestConfig_SetDefinitionInjection(c *C) {
+	var config Config
+	c.Assert(config.LoadFile("../example/config.ini"), IsNil)
+	config.Global.EtcdPrefix = "/dockership"
+
+	err := config.SetDefinition(DefinitionProject, "foo", []byte(`{
+		"x = \"\"\n[Grant \"g\"]\nUser = \"me\"\nRole = \"admin\"\nProject = \"*\"\ny": "z"
+	}`))
+
+	c.Assert(err, ErrorMatches, "Unknown variable .*")
+	c.Assert(config.Grants["g"], IsNil)
+
+	err = config.SetDefinition(DefinitionProject, "foo", []byte(`{"Foo": "bar"}`))
+	c.Assert(err, ErrorMatches, `Unknown variable "Foo"`)
+
+	_, err = parseDefinition(DefinitionProject, "foo", []byte(`{"DockerEndPoint": "tcp://dev:2375"}`))
+	c.Assert(err, ErrorMatches, `Unknown variable "DockerEndPoint"`)
+}
+
+func (s *ConfigSuite) TestConfig_isDefinition(c *C) {
+	part := &Config{}
+	c.Assert(part.isDefinition(DefinitionProject, "foo"), Equals, false)
+
+	c.Assert(gcfg.ReadStringInto(part, "[Project \"foo\"]\n"), IsNil)
+	c.Assert(part.isDefinition(DefinitionProject, "foo"), Equals, true)
+	c.Assert(part.isDefinition(DefinitionProject, "bar"), Equals, false)
+	c.Assert(part.isDefinition(DefinitionEnvironment, "foo"), Equals, false)
+
+	c.Assert(gcfg.ReadStringInto(part, "[Grant \"g\"]\nRole = admin\n"), IsNil)
+	c.Assert(part.isDefinition(DefinitionProject, "foo"), Equals, false)
+}
+
+func (s *ConfigSuite) TestConfig_DeleteDefinition(c *C) {
+	var config Config
+	c.Assert(config.LoadFile("../example/config.ini"), IsNil)
+
+	config.Global.EtcdPrefix = "/dockership"
+	c.Assert(config.SetDefinition(DefinitionEnvironment, "dev", []byte(`{"DockerEndPoint": "tcp://dev:2375"}`)), IsNil)
+	c.Assert(config.SetDefinition(DefinitionProject, "foo", []byte(`{"Environment": "dev"}`)), IsNil)
+
+	c.Assert(config.DeleteDefinition(DefinitionEnvironment, "dev"), IsNil)
+	c.Assert(config.Environments["dev"], IsNil)
+	c.Assert(config.Projects["foo"].Environments, HasLen, 0)
+	c.Assert(config.Validate().Errors(), Not(HasLen), 0)
+
+	c.Assert(config.DeleteDefinition(DefinitionEnvironment, "dev"), Equals, ErrDefinitionNotFound)
+	c.Assert(config.DeleteDefinition(DefinitionEnvironment, "live"), Equals, ErrDefinedAtFile)
+	c.Assert(config.DeleteDefinition("foo", "live"), Equals, ErrUnknownDefinition)
+}
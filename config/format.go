@@ -0,0 +1,372 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/gcfg.v1"
+	"gopkg.in/yaml.v2"
+)
+
+// The YAML, JSON and TOML config files have the same sections and keys than
+// the INI ones, the sections with subsections, like Project, are objects
+// indexed by the subsection name and the multi-valued keys are lists:
+//
+//   Project:
+//     frontend:
+//       Repository: git@github.com:company/frontend.git
+//       Environment: [live, dev]
+//
+// They are translated to INI and read with gcfg, so the same types and
+// defaults apply.
+
+type Format string
+
+const (
+	FormatINI  Format = "ini"
+	FormatYAML Format = "yaml"
+	FormatJSON Format = "json"
+	FormatTOML Format = "toml"
+)
+
+var extensions = map[string]Format{
+	".yml":  FormatYAML,
+	".yaml": FormatYAML,
+	".json": FormatJSON,
+	".toml": FormatTOML,
+}
+
+// GetFormat returns the format of the config file based on its extension, INI
+// by default
+func GetFormat(filename string) Format {
+	if f, ok := extensions[strings.ToLower(filepath.Ext(filename))]; ok {
+		return f
+	}
+
+	return FormatINI
+}
+
+// ParseFormat returns the format with the given name
+func ParseFormat(name string) (Format, error) {
+	switch f := Format(strings.ToLower(name)); f {
+	case FormatINI, FormatYAML, FormatJSON, FormatTOML:
+		return f, nil
+	case "yml":
+		return FormatYAML, nil
+	}
+
+	return "", fmt.Errorf("Unknown config format %q, expected ini, yaml, json or toml", name)
+}
+
+func readFileInto(c *Config, filename string) error {
+	format := GetFormat(filename)
+	if format == FormatINI {
+		return gcfg.ReadFileInto(c, filename)
+	}
+
+	raw, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	tree, err := decode(format, raw)
+	if err != nil {
+		return fmt.Errorf("%s: %s", filename, err)
+	}
+
+	ini, err := treeToINI(tree)
+	if err != nil {
+		return fmt.Errorf("%s: %s", filename, err)
+	}
+
+	if err := gcfg.ReadStringInto(c, ini); err != nil {
+		return fmt.Errorf("%s: %s", filename, err)
+	}
+
+	return nil
+}
+
+func decode(format Format, raw []byte) (map[string]interface{}, error) {
+	var tree map[string]interface{}
+	var err error
+	switch format {
+	case FormatYAML:
+		var m map[interface{}]interface{}
+		if err = yaml.Unmarshal(raw, &m); err == nil {
+			var v interface{}
+			v, err = toStringMap(m)
+			tree, _ = v.(map[string]interface{})
+		}
+	case FormatJSON:
+		err = json.Unmarshal(raw, &tree)
+	case FormatTOML:
+		_, err = toml.Decode(string(raw), &tree)
+	}
+
+	return tree, err
+}
+
+// toStringMap converts the map[interface{}]interface{} values returned by the
+// YAML decoder to map[string]interface{}
+func toStringMap(v interface{}) (interface{}, error) {
+	switch t := v.(type) {
+	case map[interface{}]interface{}:
+		r := make(map[string]interface{}, len(t))
+		for k, v := range t {
+			key, ok := k.(string)
+			if !ok {
+				return nil, fmt.Errorf("unexpected key %v, keys should be strings", k)
+			}
+
+			var err error
+			if r[key], err = toStringMap(v); err != nil {
+				return nil, err
+			}
+		}
+
+		return r, nil
+	case []interface{}:
+		r := make([]interface{}, len(t))
+		for i, v := range t {
+			var err error
+			if r[i], err = toStringMap(v); err != nil {
+				return nil, err
+			}
+		}
+
+		return r, nil
+	}
+
+	return v, nil
+}
+
+// treeToINI writes the decoded tree as INI, the sections are sorted by name
+func treeToINI(tree map[string]interface{}) (string, error) {
+	buf := bytes.NewBuffer(nil)
+	for _, section := range sortedKeys(tree) {
+		vars, ok := tree[section].(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf("section %q should be an object", section)
+		}
+
+		subsections := make(map[string]map[string]interface{}, 0)
+		values := make(map[string]interface{}, 0)
+		for name, v := range vars {
+			if sub, ok := v.(map[string]interface{}); ok {
+				subsections[name] = sub
+			} else {
+				values[name] = v
+			}
+		}
+
+		if len(values) != 0 {
+			fmt.Fprintf(buf, "[%s]\n", section)
+			if err := writeINIValues(buf, section, values); err != nil {
+				return "", err
+			}
+		}
+
+		for _, name := range sortedKeys(subsections) {
+			fmt.Fprintf(buf, "[%s %s]\n", section, quote(name))
+			if err := writeINIValues(buf, section+"."+name, subsections[name]); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	return buf.String(), nil
+}
+
+func writeINIValues(buf *bytes.Buffer, section string, values map[string]interface{}) error {
+	for _, key := range sortedKeys(values) {
+		list, ok := values[key].([]interface{})
+		if !ok {
+			list = []interface{}{values[key]}
+		}
+
+		for _, v := range list {
+			if v == nil {
+				continue
+			}
+
+			s, err := formatValue(v)
+			if err != nil {
+				return fmt.Errorf("%s.%s: %s", section, key, err)
+			}
+
+			fmt.Fprintf(buf, "%s = %s\n", key, quote(s))
+		}
+	}
+
+	return nil
+}
+
+func formatValue(v interface{}) (string, error) {
+	switch t := v.(type) {
+	case string:
+		return t, nil
+	case bool:
+		return strconv.FormatBool(t), nil
+	case int:
+		return strconv.Itoa(t), nil
+	case int64:
+		return strconv.FormatInt(t, 10), nil
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64), nil
+	}
+
+	return "", fmt.Errorf("unexpected value %v, expected a string, number, boolean or list", v)
+}
+
+var escaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`)
+
+func quote(s string) string {
+	return `"` + escaper.Replace(s) + `"`
+}
+
+// ConvertFile converts the INI config file to the given format, the values are
+// typed and the multi-valued keys written as lists according to Config
+func ConvertFile(filename string, format Format) ([]byte, error) {
+	raw, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	tree := make(map[string]interface{}, 0)
+	err = gcfg.ReadWithCallback(bytes.NewReader(raw), func(section, subsection, key, value string, blank bool) error {
+		vars, ok := tree[section].(map[string]interface{})
+		if !ok {
+			vars = make(map[string]interface{}, 0)
+			tree[section] = vars
+		}
+
+		if subsection != "" {
+			sub, ok := vars[subsection].(map[string]interface{})
+			if !ok {
+				sub = make(map[string]interface{}, 0)
+				vars[subsection] = sub
+			}
+
+			vars = sub
+		}
+
+		if key == "" {
+			return nil
+		}
+
+		kind, multi := getKind(section, key)
+		if !multi {
+			if blank {
+				value = "true"
+			}
+
+			vars[key] = typedValue(kind, value)
+			return nil
+		}
+
+		list, _ := vars[key].([]interface{})
+		if blank {
+			list = []interface{}{}
+		} else {
+			list = append(list, typedValue(kind, value))
+		}
+
+		vars[key] = list
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", filename, err)
+	}
+
+	switch format {
+	case FormatYAML:
+		return yaml.Marshal(tree)
+	case FormatJSON:
+		buf := bytes.NewBuffer(nil)
+		e := json.NewEncoder(buf)
+		e.SetEscapeHTML(false)
+		e.SetIndent("", "  ")
+		err := e.Encode(tree)
+		return buf.Bytes(), err
+	case FormatTOML:
+		buf := bytes.NewBuffer(nil)
+		err := toml.NewEncoder(buf).Encode(tree)
+		return buf.Bytes(), err
+	}
+
+	return raw, nil
+}
+
+// getKind returns the kind of the Config field for the given section and key,
+// and if it is multi-valued
+func getKind(section, key string) (reflect.Kind, bool) {
+	t, ok := fieldType(reflect.TypeOf(Config{}), section)
+	if !ok {
+		return reflect.String, false
+	}
+
+	if t.Kind() == reflect.Map {
+		t = t.Elem()
+	}
+
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	f, ok := fieldType(t, key)
+	if !ok {
+		return reflect.String, false
+	}
+
+	if f.Kind() == reflect.Slice {
+		return f.Elem().Kind(), true
+	}
+
+	return f.Kind(), false
+}
+
+// fieldType returns the type of the field matching the name as gcfg does, by
+// its gcfg tag or its name ignoring the case
+func fieldType(t reflect.Type, name string) (reflect.Type, bool) {
+	if t.Kind() != reflect.Struct {
+		return nil, false
+	}
+
+	name = strings.Replace(name, "-", "_", -1)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		fname := f.Name
+		if tag := f.Tag.Get("gcfg"); tag != "" {
+			fname = tag
+		}
+
+		if strings.EqualFold(fname, name) {
+			return f.Type, true
+		}
+	}
+
+	return nil, false
+}
+
+func typedValue(kind reflect.Kind, value string) interface{} {
+	switch kind {
+	case reflect.Bool:
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	case reflect.Int, reflect.Int64:
+		if i, err := strconv.Atoi(value); err == nil {
+			return i
+		}
+	}
+
+	return value
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"os"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *ConfigSuite) TestGetFormat(c *C) {
+	c.Assert(GetFormat("/etc/dockership/dockership.conf"), Equals, FormatINI)
+	c.Assert(GetFormat("dockership.yml"), Equals, FormatYAML)
+	c.Assert(GetFormat("dockership.YAML"), Equals, FormatYAML)
+	c.Assert(GetFormat("dockership.json"), Equals, FormatJSON)
+	c.Assert(GetFormat("dockership.toml"), Equals, FormatTOML)
+}
+
+func (s *ConfigSuite) TestConfig_LoadFileJSON(c *C) {
+	var config Config
+	err := config.LoadFile(writeConfigFileWithExt(".json", `{
+  "Global": {"GithubToken": "foo", "Reconcile": true, "ReconcileInterval": 30},
+  "Project": {
+    "a": {
+      "Repository": "git@github.com:my-company/a.git",
+      "Environment": ["live", "dev"],
+      "Port": "0.0.0.0:80:80/tcp",
+      "History": 5,
+      "PostDeployCommand": "echo \"done\"\nls"
+    }
+  },
+  "Environment": {
+    "live": {"DockerEndPoint": ["http://live-1:4243", "http://live-2:4243"]},
+    "dev": {"DockerEndPoint": ["http://dev:4243"]}
+  }
+}`))
+
+	c.Assert(err, IsNil)
+	c.Assert(config.Global.Reconcile, Equals, true)
+	c.Assert(config.Global.ReconcileInterval, Equals, 30)
+	c.Assert(config.Global.Database, Equals, "/var/lib/dockership/dockership.db")
+
+	p := config.Projects["a"]
+	c.Assert(p.GithubToken, Equals, "foo")
+	c.Assert(p.EnvironmentNames, DeepEquals, []string{"live", "dev"})
+	c.Assert(p.Ports, DeepEquals, []string{"0.0.0.0:80:80/tcp"})
+	c.Assert(p.History, Equals, 5)
+	c.Assert(p.Dockerfile, Equals, "Dockerfile")
+	c.Assert(p.PostDeployCommand, Equals, "echo \"done\"\nls")
+	c.Assert(p.Environments["live"].DockerEndPoints, HasLen, 2)
+}
+
+func (s *ConfigSuite) TestConfig_LoadFileJSONInvalid(c *C) {
+	var config Config
+	err := config.LoadFile(writeConfigFileWithExt(".json", `{"Project": {"a": {"Port": {"foo": 1}}}}`))
+	c.Assert(err, ErrorMatches, `.*\.json: Project\.a\.Port: unexpected value .*`)
+}
+
+func (s *ConfigSuite) TestConvertFile(c *C) {
+	raw, err := ConvertFile("../example/config.ini", FormatJSON)
+	c.Assert(err, IsNil)
+
+	var ini, json Config
+	c.Assert(ini.LoadFile("../example/config.ini"), IsNil)
+	c.Assert(json.LoadFile(writeConfigFileWithExt(".json", string(raw))), IsNil)
+
+	c.Assert(json.Global, DeepEquals, ini.Global)
+	c.Assert(json.Projects["project"].Ports, DeepEquals, ini.Projects["project"].Ports)
+	c.Assert(json.Projects["project"].EnvironmentNames, DeepEquals, ini.Projects["project"].EnvironmentNames)
+	c.Assert(json.Projects["other-project"].LinkNames, DeepEquals, ini.Projects["other-project"].LinkNames)
+	c.Assert(json.Environments["testing"].EtcdServers, DeepEquals, ini.Environments["testing"].EtcdServers)
+}
+
+func writeConfigFileWithExt(ext, content string) string {
+	file := writeConfigFile(content)
+	if err := os.Rename(file, file+ext); err != nil {
+		panic(err)
+	}
+
+	return file + ext
+}
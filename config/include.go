@@ -0,0 +1,237 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/mcuadros/dockership/core"
+)
+
+const sectionGlobal = "Global"
+
+// readFiles reads the config file, or every file at the config directory, and
+// the files matching the Include patterns, the sections are merged and the
+// file defining each of them is recorded
+func (c *Config) readFiles(filename string) error {
+	c.sources = make(map[string]string, 0)
+
+	files, err := getConfigFiles(filename)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < len(files); i++ {
+		part := &Config{}
+		if err := readFileInto(part, files[i]); err != nil {
+			return err
+		}
+
+		if err := interpolate(part); err != nil {
+			return fmt.Errorf("%s: %s", files[i], err)
+		}
+
+		included, err := getIncludedFiles(files[i], part.Global.Includes, files)
+		if err != nil {
+			return err
+		}
+
+		files = append(files, included...)
+		if err := c.merge(files[i], part); err != nil {
+			return err
+		}
+	}
+
+	if _, ok := c.sources[sectionGlobal]; !ok {
+		c.sources[sectionGlobal] = files[0]
+	}
+
+	return nil
+}
+
+// getConfigFiles returns the config file or, if it is a directory, the files
+// on it sorted by name, the hidden ones are ignored
+func getConfigFiles(filename string) ([]string, error) {
+	fi, err := os.Stat(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	if !fi.IsDir() {
+		return []string{filename}, nil
+	}
+
+	entries, err := ioutil.ReadDir(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, e := range entries {
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+
+		files = append(files, filepath.Join(filename, e.Name()))
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("%s: no config files found", filename)
+	}
+
+	return files, nil
+}
+
+// getIncludedFiles returns the files matching the patterns, relative to the
+// directory of the file including them, skipping the ones already read
+func getIncludedFiles(from string, patterns, read []string) ([]string, error) {
+	var files []string
+	for _, pattern := range patterns {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(filepath.Dir(from), pattern)
+		}
+
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%s: Include: %s", from, err)
+		}
+
+		sort.Strings(matches)
+		for _, m := range matches {
+			if fi, err := os.Stat(m); err == nil && !fi.IsDir() && !contains(read, m) && !contains(files, m) {
+				files = append(files, m)
+			}
+		}
+	}
+
+	return files, nil
+}
+
+// merge adds the sections of the config read from the file, the Global, HTTP
+// and Audit sections can only be defined at one file and the templates,
+// projects, overrides, environments and grants only once
+func (c *Config) merge(file string, part *Config) error {
+	if part.hasSettings() {
+		if f, ok := c.sources[sectionGlobal]; ok {
+			return fmt.Errorf(
+				"%s: the Global, HTTP and Audit sections are already defined at %s", file, f,
+			)
+		}
+
+		c.Global, c.HTTP, c.Audit = part.Global, part.HTTP, part.Audit
+		c.sources[sectionGlobal] = file
+	}
+
+	if c.Templates == nil {
+		c.Templates = make(map[string]*core.Project, 0)
+	}
+
+	for name, t := range part.Templates {
+		if err := c.addSource(file, "Template", name); err != nil {
+			return err
+		}
+
+		c.Templates[name] = t
+	}
+
+	if c.Projects == nil {
+		c.Projects = make(map[string]*core.Project, 0)
+	}
+
+	for name, p := range part.Projects {
+		if err := c.addSource(file, "Project", name); err != nil {
+			return err
+		}
+
+		c.Projects[name] = p
+	}
+
+	if c.ProjectEnvironments == nil {
+		c.ProjectEnvironments = make(map[string]*core.Project, 0)
+	}
+
+	for name, o := range part.ProjectEnvironments {
+		if err := c.addSource(file, "ProjectEnvironment", name); err != nil {
+			return err
+		}
+
+		c.ProjectEnvironments[name] = o
+	}
+
+	if c.Environments == nil {
+		c.Environments = make(map[string]*core.Environment, 0)
+	}
+
+	for name, e := range part.Environments {
+		if err := c.addSource(file, "Environment", name); err != nil {
+			return err
+		}
+
+		c.Environments[name] = e
+	}
+
+	if c.Grants == nil {
+		c.Grants = make(map[string]*Grant, 0)
+	}
+
+	for name, g := range part.Grants {
+		if err := c.addSource(file, "Grant", name); err != nil {
+			return err
+		}
+
+		c.Grants[name] = g
+	}
+
+	return nil
+}
+
+func (c *Config) addSource(file, kind, name string) error {
+	section := sectionName(kind, name)
+	if f, ok := c.sources[section]; ok {
+		return fmt.Errorf("%s: %s already defined at %s", file, section, f)
+	}
+
+	c.sources[section] = file
+	return nil
+}
+
+// hasSettings returns true if any value of the Global, HTTP or Audit sections
+// was set
+func (c *Config) hasSettings() bool {
+	var zero Config
+	return !reflect.DeepEqual(c.Global, zero.Global) ||
+		!reflect.DeepEqual(c.HTTP, zero.HTTP) ||
+		c.Audit != zero.Audit
+}
+
+// getSource returns the file defining the section
+func (c *Config) getSource(section string) string {
+	return c.sources[section]
+}
+
+// withFile prefixes the message with the file name, if known
+func withFile(file, msg string) string {
+	if file == "" {
+		return msg
+	}
+
+	return fmt.Sprintf("%s: %s", file, msg)
+}
+
+func sectionName(kind, name string) string {
+	return fmt.Sprintf("%s %q", kind, name)
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,127 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// The string values of the config files can reference environment variables,
+// ${NAME}, and files, ${file:/run/secrets/name}, the file content is used
+// without the trailing new lines. A literal $ can be written as $$.
+
+var reference = regexp.MustCompile(`\$\$|\$\{([^}]*)\}`)
+
+// interpolate replaces the references at every string value of the config
+func interpolate(c *Config) error {
+	v := reflect.ValueOf(c).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Type().Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+
+		switch field := v.Field(i); field.Kind() {
+		case reflect.Struct:
+			if err := interpolateStruct(f.Name, field); err != nil {
+				return err
+			}
+		case reflect.Map:
+			for _, name := range sortedKeys(field.Interface()) {
+				section := sectionName(gcfgName(f), name)
+				value := field.MapIndex(reflect.ValueOf(name))
+				if value.IsNil() {
+					continue
+				}
+
+				if err := interpolateStruct(section, value.Elem()); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func interpolateStruct(section string, v reflect.Value) error {
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Type().Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+
+		field := v.Field(i)
+		values := []reflect.Value{field}
+		if field.Kind() == reflect.Slice {
+			values = nil
+			for j := 0; j < field.Len(); j++ {
+				values = append(values, field.Index(j))
+			}
+		}
+
+		for _, value := range values {
+			if value.Kind() != reflect.String {
+				continue
+			}
+
+			s, err := expand(value.String())
+			if err != nil {
+				return fmt.Errorf("%s: %s: %s", section, gcfgName(f), err)
+			}
+
+			value.SetString(s)
+		}
+	}
+
+	return nil
+}
+
+func expand(s string) (string, error) {
+	var err error
+	r := reference.ReplaceAllStringFunc(s, func(ref string) string {
+		if ref == "$$" || err != nil {
+			return "$"
+		}
+
+		var value string
+		value, err = resolveReference(ref[2 : len(ref)-1])
+		return value
+	})
+
+	return r, err
+}
+
+func resolveReference(name string) (string, error) {
+	if strings.HasPrefix(name, "file:") {
+		content, err := ioutil.ReadFile(strings.TrimPrefix(name, "file:"))
+		if err != nil {
+			return "", fmt.Errorf("${%s}: %s", name, err)
+		}
+
+		return strings.TrimRight(string(content), "\r\n"), nil
+	}
+
+	if name == "" {
+		return "", fmt.Errorf("empty reference ${}")
+	}
+
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("${%s}: environment variable %q is not defined", name, name)
+	}
+
+	return value, nil
+}
+
+// gcfgName returns the name of the section or key of the field
+func gcfgName(f reflect.StructField) string {
+	if tag := f.Tag.Get("gcfg"); tag != "" {
+		return tag
+	}
+
+	return f.Name
+}
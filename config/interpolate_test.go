@@ -0,0 +1,67 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *ConfigSuite) TestConfig_LoadFileInterpolation(c *C) {
+	dir := c.MkDir()
+	writeFile(dir+"/secret", "s3cr3t\n")
+	os.Setenv("DOCKERSHIP_TEST_TOKEN", "token")
+	defer os.Unsetenv("DOCKERSHIP_TEST_TOKEN")
+
+	writeFile(dir+"/dockership.conf", `
+[Global]
+GithubToken = ${DOCKERSHIP_TEST_TOKEN}
+
+[HTTP]
+GithubSecret = ${file:`+dir+`/secret}
+
+[Project "a"]
+Repository = git@github.com:my-company/a.git
+Env = PASSWORD=${file:`+dir+`/secret}
+Env = PRICE=$$5
+WebHook = http://hooks.my-company.com/${DOCKERSHIP_TEST_TOKEN}
+`)
+
+	var config Config
+	c.Assert(config.LoadFile(dir+"/dockership.conf"), IsNil)
+	c.Assert(config.Global.GithubToken, Equals, "token")
+	c.Assert(config.HTTP.GithubSecret, Equals, "s3cr3t")
+
+	p := config.Projects["a"]
+	c.Assert(p.GithubToken, Equals, "token")
+	c.Assert(p.Env, DeepEquals, []string{"PASSWORD=s3cr3t", "PRICE=$5"})
+	c.Assert(p.WebHook, Equals, "http://hooks.my-company.com/token")
+
+	raw, err := json.Marshal(p)
+	c.Assert(err, IsNil)
+	c.Assert(string(raw), Not(Matches), "(?s).*(token|s3cr3t).*")
+
+	raw, err = json.Marshal(config)
+	c.Assert(err, IsNil)
+	c.Assert(string(raw), Not(Matches), "(?s).*(token|s3cr3t).*")
+}
+
+func (s *ConfigSuite) TestConfig_LoadFileInterpolationMissing(c *C) {
+	os.Unsetenv("DOCKERSHIP_TEST_MISSING")
+
+	var config Config
+	err := config.LoadFile(writeConfigFile(`
+[Project "a"]
+Repository = git@github.com:my-company/a.git
+GithubToken = ${DOCKERSHIP_TEST_MISSING}
+`))
+
+	c.Assert(err, ErrorMatches, `.*: Project "a": GithubToken: \${DOCKERSHIP_TEST_MISSING}: environment variable "DOCKERSHIP_TEST_MISSING" is not defined`)
+
+	err = config.LoadFile(writeConfigFile(`
+[HTTP]
+GithubSecret = ${file:/non/existent}
+`))
+
+	c.Assert(err, ErrorMatches, `.*: HTTP: GithubSecret: \${file:/non/existent}: open /non/existent: no such file or directory`)
+}
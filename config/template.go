@@ -0,0 +1,61 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mcuadros/dockership/core"
+)
+
+// resolveTemplates sets, at every project extending a template, the settings
+// inherited from the template and the templates it extends
+func (c *Config) resolveTemplates() error {
+	if c.resolved == nil {
+		c.resolved = make(map[*core.Project]bool, 0)
+	}
+
+	for _, name := range sortedKeys(c.Projects) {
+		p := c.Projects[name]
+		if p.Extends == "" || c.resolved[p] {
+			continue
+		}
+
+		section := sectionName("Project", name)
+		t, err := c.resolveTemplate(p.Extends, nil)
+		if err != nil {
+			return fmt.Errorf("%s", withFile(c.getSource(section), fmt.Sprintf("%s: %s", section, err)))
+		}
+
+		p.Inherit(t)
+		c.resolved[p] = true
+	}
+
+	return nil
+}
+
+// resolveTemplate returns a copy of the template with the settings inherited
+// from the templates it extends, the chain is used to detect cycles
+func (c *Config) resolveTemplate(name string, chain []string) (*core.Project, error) {
+	chain = append(chain, name)
+	if contains(chain[:len(chain)-1], name) {
+		return nil, fmt.Errorf("Cyclic templates: %s", strings.Join(chain, " -> "))
+	}
+
+	t, ok := c.Templates[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown template %q", name)
+	}
+
+	r := *t
+	if t.Extends == "" {
+		return &r, nil
+	}
+
+	parent, err := c.resolveTemplate(t.Extends, chain)
+	if err != nil {
+		return nil, err
+	}
+
+	r.Inherit(parent)
+	return &r, nil
+}
@@ -0,0 +1,99 @@
+package config
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+func (s *ConfigSuite) TestConfig_LoadFileTemplate(c *C) {
+	var config Config
+	err := config.LoadFile(writeConfigFile(`
+[Template "base"]
+Environment = live
+Restart = always
+History = 5
+File = config.json
+
+[Template "service"]
+Extends = base
+Environment = testing
+Volume = /data:/data
+
+[Project "a"]
+Extends = service
+Repository = git@github.com:my-company/a.git
+Port = 0.0.0.0:8080:80/tcp
+File = secrets.json
+
+[Project "b"]
+Extends = service
+Repository = git@github.com:my-company/b.git
+History = 1
+Volume = /tmp:/data
+Replace = Volume
+
+[Environment "live"]
+DockerEndPoint = http://live:4243
+
+[Environment "testing"]
+DockerEndPoint = http://testing:4243
+`))
+
+	c.Assert(err, IsNil)
+
+	a := config.Projects["a"]
+	c.Assert(a.EnvironmentNames, DeepEquals, []string{"live", "testing"})
+	c.Assert(a.Environments, HasLen, 2)
+	c.Assert(a.Restart, Equals, "always")
+	c.Assert(a.History, Equals, 5)
+	c.Assert(a.Files, DeepEquals, []string{"config.json", "secrets.json"})
+	c.Assert(a.Binds, DeepEquals, []string{"/data:/data"})
+	c.Assert(a.Dockerfile, Equals, "Dockerfile")
+
+	b := config.Projects["b"]
+	c.Assert(b.History, Equals, 1)
+	c.Assert(b.Binds, DeepEquals, []string{"/tmp:/data"})
+	c.Assert(config.Templates["service"].Binds, DeepEquals, []string{"/data:/data"})
+	c.Assert(config.Validate().Errors(), HasLen, 0)
+}
+
+func (s *ConfigSuite) TestConfig_LoadFileTemplateUnknown(c *C) {
+	var config Config
+	err := config.LoadFile(writeConfigFile(`
+[Project "a"]
+Extends = base
+Repository = git@github.com:my-company/a.git
+`))
+
+	c.Assert(err, ErrorMatches, `.*: Project "a": unknown template "base"`)
+}
+
+func (s *ConfigSuite) TestConfig_LoadFileTemplateCyclic(c *C) {
+	var config Config
+	err := config.LoadFile(writeConfigFile(`
+[Template "a"]
+Extends = b
+
+[Template "b"]
+Extends = a
+
+[Project "a"]
+Extends = a
+Repository = git@github.com:my-company/a.git
+`))
+
+	c.Assert(err, ErrorMatches, `.*: Project "a": Cyclic templates: a -> b -> a`)
+}
+
+func (s *ConfigSuite) TestConfig_ValidateTemplate(c *C) {
+	var config Config
+	c.Assert(config.ReadFile(writeConfigFile(`
+[Template "base"]
+Restart = sometimes
+Replace = Restart
+`)), IsNil)
+
+	problems := config.Validate().Errors()
+	c.Assert(problems, HasLen, 2)
+	c.Assert(problems[0].Error(), Matches, `.*: Template "base": Restart: Malformed restart policy "sometimes"`)
+	c.Assert(problems[1].Error(), Matches, `.*: Template "base": Replace: "Restart" is not a multi-valued key`)
+}
@@ -0,0 +1,351 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/mcuadros/dockership/core"
+)
+
+// Problem is an error or a warning found validating the config, at the given
+// section and key of the file
+type Problem struct {
+	File    string `json:",omitempty"`
+	Section string
+	Key     string
+	Message string
+	Warning bool
+}
+
+func (p *Problem) Error() string {
+	msg := p.Message
+	if p.Key != "" {
+		msg = fmt.Sprintf("%s: %s", p.Key, msg)
+	}
+
+	return withFile(p.File, fmt.Sprintf("%s: %s", p.Section, msg))
+}
+
+type Problems []*Problem
+
+// Errors returns the problems that are not warnings
+func (ps Problems) Errors() Problems {
+	var r Problems
+	for _, p := range ps {
+		if !p.Warning {
+			r = append(r, p)
+		}
+	}
+
+	return r
+}
+
+// Warnings returns the problems that are warnings
+func (ps Problems) Warnings() Problems {
+	var r Problems
+	for _, p := range ps {
+		if p.Warning {
+			r = append(r, p)
+		}
+	}
+
+	return r
+}
+
+func (ps Problems) Error() string {
+	msgs := make([]string, len(ps))
+	for i, p := range ps {
+		msgs[i] = p.Error()
+	}
+
+	return strings.Join(msgs, "\n")
+}
+
+func (ps *Problems) addError(section, key, format string, args ...interface{}) {
+	*ps = append(*ps, &Problem{Section: section, Key: key, Message: fmt.Sprintf(format, args...)})
+}
+
+func (ps *Problems) addWarning(section, key, format string, args ...interface{}) {
+	*ps = append(*ps, &Problem{
+		Section: section, Key: key, Message: fmt.Sprintf(format, args...), Warning: true,
+	})
+}
+
+// Validate checks the whole config and returns every problem found, grouped by
+// section
+func (c *Config) Validate() Problems {
+	var ps Problems
+	if c.Global.ReconcileInterval <= 0 {
+		ps.addError(sectionGlobal, "ReconcileInterval", "should be greater than 0")
+	}
+
+	for _, p := range c.HTTP.TrustedProxies {
+		if _, _, err := net.ParseCIDR(p); err != nil && net.ParseIP(p) == nil {
+			ps.addError("HTTP", "TrustedProxy", "invalid IP address or CIDR range %q", p)
+		}
+	}
+
+	for _, name := range sortedKeys(c.Templates) {
+		c.validateTemplate(&ps, name, c.Templates[name])
+	}
+
+	for _, name := range sortedKeys(c.Projects) {
+		c.validateProject(&ps, name, c.Projects[name])
+	}
+
+	for _, name := range sortedKeys(c.ProjectEnvironments) {
+		c.validateProjectEnvironment(&ps, name, c.ProjectEnvironments[name])
+	}
+
+	for _, name := range sortedKeys(c.Environments) {
+		c.validateEnvironment(&ps, name, c.Environments[name])
+	}
+
+	c.validateLinks(&ps)
+	c.validateGrants(&ps)
+
+	for _, p := range ps {
+		p.File = c.getSource(p.Section)
+	}
+
+	return ps
+}
+
+func (c *Config) validateProject(ps *Problems, name string, p *core.Project) {
+	section := sectionName("Project", name)
+	if p.Repository == "" {
+		ps.addError(section, "Repository", "is mandatory")
+	} else if !p.Repository.IsValid() {
+		ps.addError(section, "Repository", "invalid repository %q", p.Repository)
+	}
+
+	for _, r := range p.RelatedRepositories {
+		if !r.IsValid() {
+			ps.addError(section, "RelatedRepository", "invalid repository %q", r)
+		}
+	}
+
+	if len(p.EnvironmentNames) == 0 {
+		ps.addWarning(section, "Environment", "no environment defined, the project can't be deployed")
+	}
+
+	for _, e := range p.EnvironmentNames {
+		if _, ok := c.Environments[e]; !ok {
+			ps.addError(section, "Environment", "unknown environment %q", e)
+		}
+	}
+
+	for _, l := range p.LinkNames {
+		if _, ok := c.Projects[l.GetProjectName()]; !ok {
+			ps.addWarning(section, "Link", "unknown project %q, linked as an external container", l.GetProjectName())
+		}
+	}
+
+	validateSettings(ps, section, p)
+	validateReplace(ps, section, p)
+}
+
+func (c *Config) validateTemplate(ps *Problems, name string, t *core.Project) {
+	section := sectionName("Template", name)
+	if t.Repository != "" && !t.Repository.IsValid() {
+		ps.addError(section, "Repository", "invalid repository %q", t.Repository)
+	}
+
+	for _, e := range t.EnvironmentNames {
+		if _, ok := c.Environments[e]; !ok {
+			ps.addError(section, "Environment", "unknown environment %q", e)
+		}
+	}
+
+	validateSettings(ps, section, t)
+	validateReplace(ps, section, t)
+}
+
+// validateReplace checks the keys at Replace are multi-valued keys
+func validateReplace(ps *Problems, section string, p *core.Project) {
+	for _, key := range p.Replace {
+		t, ok := fieldType(reflect.TypeOf(core.Project{}), key)
+		if !ok || t.Kind() != reflect.Slice || strings.EqualFold(key, "Replace") {
+			ps.addError(section, "Replace", "%q is not a multi-valued key", key)
+		}
+	}
+}
+
+// validateSettings checks the settings that can be overridden by environment
+func validateSettings(ps *Problems, section string, p *core.Project) {
+	for _, port := range p.Ports {
+		if err := core.CheckPort(port); err != nil {
+			ps.addError(section, "Port", "%s", err)
+		}
+	}
+
+	if err := core.CheckRestart(p.Restart); err != nil {
+		ps.addError(section, "Restart", "%s", err)
+	}
+
+	if _, err := core.ParseMemory(p.Memory); err != nil {
+		ps.addError(section, "Memory", "%s", err)
+	}
+
+	if p.CPUShares < 0 {
+		ps.addError(section, "CPUShares", "should be positive")
+	}
+}
+
+func (c *Config) validateProjectEnvironment(ps *Problems, name string, o *core.Project) {
+	section := sectionName("ProjectEnvironment", name)
+	project, env := SplitProjectEnvironment(name)
+	p, ok := c.Projects[project]
+	if !ok {
+		ps.addError(section, "", "unknown project %q, the name should be <project>.<environment>", project)
+		return
+	}
+
+	if !p.HasEnvironment(env) {
+		ps.addError(section, "", "environment %q not defined at project %q", env, project)
+	}
+
+	if o.Repository != "" && !o.Repository.IsValid() {
+		ps.addError(section, "Repository", "invalid repository %q", o.Repository)
+	}
+
+	for _, err := range core.CheckOverride(o) {
+		ps.addError(section, "", "%s", err)
+	}
+
+	validateSettings(ps, section, o)
+}
+
+func (c *Config) validateEnvironment(ps *Problems, name string, e *core.Environment) {
+	section := sectionName("Environment", name)
+	if len(e.DockerEndPoints) == 0 {
+		ps.addError(section, "DockerEndPoint", "at least one docker end point is required")
+	}
+
+	for _, ep := range e.DockerEndPoints {
+		if err := checkEndPoint(ep); err != nil {
+			ps.addError(section, "DockerEndPoint", "%s", err)
+		}
+	}
+
+	if e.HookEndPoint != "" && !e.HasDockerEndPoint(e.HookEndPoint) {
+		ps.addError(section, "HookEndPoint", "%q is not one of the docker end points", e.HookEndPoint)
+	}
+
+	var projects []*core.Project
+	for _, pname := range sortedKeys(c.Projects) {
+		p := c.Projects[pname]
+		for _, en := range p.EnvironmentNames {
+			if en == name {
+				projects = append(projects, p.ForEnvironment(name))
+				break
+			}
+		}
+	}
+
+	if len(projects) == 0 {
+		ps.addWarning(section, "", "not used by any project")
+	}
+
+	c.validateHostPorts(ps, section, e, projects)
+}
+
+// validateHostPorts warns about host ports bound by more than one project at
+// the environment, a port bound to every interface conflicts with any other
+func (c *Config) validateHostPorts(ps *Problems, section string, e *core.Environment, projects []*core.Project) {
+	type binding struct{ ip, port, project string }
+
+	var bindings []binding
+	for _, p := range projects {
+		for _, hp := range p.HostPorts(e) {
+			i := strings.LastIndex(hp, ":")
+			bindings = append(bindings, binding{hp[:i], hp[i+1:], p.Name})
+		}
+	}
+
+	for i, a := range bindings {
+		for _, b := range bindings[i+1:] {
+			if a.port != b.port || a.project == b.project {
+				continue
+			}
+
+			if a.ip == b.ip || isAnyIP(a.ip) || isAnyIP(b.ip) {
+				ps.addWarning(section, "Port", "host port %s bound by projects %q and %q", a.port, a.project, b.project)
+			}
+		}
+	}
+}
+
+func (c *Config) validateLinks(ps *Problems) {
+	if err := c.ValidateLinks(); err != nil {
+		ps.addError("Project", "Link", "%s", err)
+	}
+}
+
+func (c *Config) validateGrants(ps *Problems) {
+	for _, name := range sortedKeys(c.Grants) {
+		g := c.Grants[name]
+		section := sectionName("Grant", name)
+		if _, err := ParseRole(g.Role); err != nil {
+			ps.addError(section, "Role", "%s", err)
+		}
+
+		for _, p := range g.Projects {
+			if _, ok := c.Projects[p]; !ok && p != "*" {
+				ps.addError(section, "Project", "unknown project %q", p)
+			}
+		}
+
+		for _, e := range g.Environments {
+			if _, ok := c.Environments[e]; !ok && e != "*" {
+				ps.addError(section, "Environment", "unknown environment %q", e)
+			}
+		}
+
+		if len(g.Users) == 0 && len(g.Teams) == 0 {
+			ps.addWarning(section, "", "no user or team defined, the grant has no effect")
+		}
+	}
+}
+
+func checkEndPoint(endPoint string) error {
+	u, err := url.Parse(endPoint)
+	if err != nil {
+		return fmt.Errorf("invalid end point %q", endPoint)
+	}
+
+	switch u.Scheme {
+	case "http", "https", "tcp":
+		if u.Host == "" {
+			return fmt.Errorf("invalid end point %q, missing host", endPoint)
+		}
+	case "unix":
+		if u.Path == "" {
+			return fmt.Errorf("invalid end point %q, missing socket path", endPoint)
+		}
+	default:
+		return fmt.Errorf("invalid end point %q, unsupported scheme %q", endPoint, u.Scheme)
+	}
+
+	return nil
+}
+
+func isAnyIP(ip string) bool {
+	return ip == "" || ip == "0.0.0.0"
+}
+
+// sortedKeys returns the keys of a map indexed by strings, sorted
+func sortedKeys(m interface{}) []string {
+	v := reflect.ValueOf(m)
+	keys := make([]string, 0, v.Len())
+	for _, k := range v.MapKeys() {
+		keys = append(keys, k.String())
+	}
+
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,126 @@
+package config
+
+import (
+	"strings"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *ConfigSuite) TestConfig_ValidateExample(c *C) {
+	var config Config
+	c.Assert(config.LoadFile("../example/config.ini"), IsNil)
+	c.Assert(config.Validate().Errors(), HasLen, 0)
+
+	warnings := config.Validate().Warnings()
+	c.Assert(warnings, HasLen, 1)
+	c.Assert(warnings[0].Error(), Matches, `.*Project "other-project": Link: unknown project "mysql", linked as an external container`)
+}
+
+func (s *ConfigSuite) TestConfig_Validate(c *C) {
+	file := writeConfigFile(`
+[Project "a"]
+Repository = foo
+Environment = live
+Environment = qux
+Port = 80:80
+Port = 0.0.0.0:80:80/tcp
+Restart = sometimes
+
+[Project "b"]
+Repository = git@github.com:my-company/b.git
+Environment = live
+Port = 10.0.0.1:80:8080/tcp
+Port = 10.0.0.1:53:53/udp
+
+[Project "c"]
+Repository = git@github.com:my-company/c.git
+
+[Environment "live"]
+DockerEndPoint = http://live:4243
+DockerEndPoint = ftp://live:4243
+HookEndPoint = tcp://other:4243
+
+[Environment "empty"]
+
+[Grant "g"]
+Role = root
+Project = d
+`)
+
+	var config Config
+	c.Assert(config.ReadFile(file), IsNil)
+
+	problems := config.Validate()
+	for _, p := range problems {
+		c.Assert(p.File, Equals, file)
+	}
+
+	c.Assert(strings.Replace(problems.Error(), file+": ", "", -1), Equals, `Project "a": Repository: invalid repository "foo"
+Project "a": Environment: unknown environment "qux"
+Project "a": Port: Malformed port "80:80"
+Project "a": Restart: Malformed restart policy "sometimes"
+Project "c": Environment: no environment defined, the project can't be deployed
+Environment "empty": DockerEndPoint: at least one docker end point is required
+Environment "empty": not used by any project
+Environment "live": DockerEndPoint: invalid end point "ftp://live:4243", unsupported scheme "ftp"
+Environment "live": HookEndPoint: "tcp://other:4243" is not one of the docker end points
+Environment "live": Port: host port 80/tcp bound by projects "a" and "b"
+Grant "g": Role: Unknown role "root", expected viewer, deployer or admin
+Grant "g": Project: unknown project "d"
+Grant "g": no user or team defined, the grant has no effect`)
+
+	c.Assert(problems.Errors(), HasLen, 9)
+	c.Assert(problems.Warnings(), HasLen, 4)
+}
+
+func (s *ConfigSuite) TestConfig_ValidateProjectEnvironment(c *C) {
+	file := writeConfigFile(`
+[Project "a"]
+Repository = git@github.com:my-company/a.git
+Environment = live
+Memory = 1t
+
+[Project "b"]
+Repository = git@github.com:my-company/b.git
+Environment = live
+Port = 0.0.0.0:8080:80/tcp
+
+[ProjectEnvironment "a.live"]
+Port = 0.0.0.0:8080:80/tcp
+Memory = 512m
+Environment = testing
+
+[ProjectEnvironment "a.testing"]
+Restart = sometimes
+
+[ProjectEnvironment "c.live"]
+
+[Environment "live"]
+DockerEndPoint = http://live:4243
+`)
+
+	var config Config
+	c.Assert(config.ReadFile(file), IsNil)
+
+	problems := config.Validate()
+	c.Assert(strings.Replace(problems.Error(), file+": ", "", -1), Equals, `Project "a": Memory: Malformed memory limit "1t"
+ProjectEnvironment "a.live": Environment can't be overridden by environment
+ProjectEnvironment "a.testing": environment "testing" not defined at project "a"
+ProjectEnvironment "a.testing": Restart: Malformed restart policy "sometimes"
+ProjectEnvironment "c.live": unknown project "c", the name should be <project>.<environment>
+Environment "live": Port: host port 8080/tcp bound by projects "a" and "b"`)
+}
+
+func (s *ConfigSuite) TestConfig_ValidateTrustedProxy(c *C) {
+	var config Config
+	c.Assert(config.ReadFile(writeConfigFile(`
+[HTTP]
+TrustedProxy = 10.0.0.1
+TrustedProxy = 10.0.0.0/8
+TrustedProxy = proxy
+`)), IsNil)
+
+	problems := config.Validate().Errors()
+	c.Assert(problems, HasLen, 1)
+	c.Assert(problems[0].Error(), Matches, `.*HTTP: TrustedProxy: invalid IP address or CIDR range "proxy"`)
+}
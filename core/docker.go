@@ -48,14 +48,33 @@ func NewDocker(endPoint string, env *Environment) (*Docker, error) {
 func (d *Docker) Deploy(p *Project, rev Revision, dockerfile *Dockerfile, output io.Writer, force bool) error {
 	Debug("Deploying dockerfile", "project", p, "revision", rev, "end-point", d.endPoint)
 
-	if err := d.cleanImages(p); err != nil {
+	if err := d.Build(p, rev, dockerfile, output); err != nil {
 		return err
 	}
 
-	if err := d.BuildImage(p, rev, dockerfile, output); err != nil {
+	if err := d.RunPreDeploy(p, rev, output); err != nil {
+		return err
+	}
+
+	if err := d.Replace(p, rev); err != nil {
+		return err
+	}
+
+	return d.RunPostDeploy(p, rev, output)
+}
+
+// Build removes the old images and builds the image of the given revision
+func (d *Docker) Build(p *Project, rev Revision, dockerfile *Dockerfile, output io.Writer) error {
+	if err := d.cleanImages(p); err != nil {
 		return err
 	}
 
+	return d.BuildImage(p, rev, dockerfile, output)
+}
+
+// Replace removes the current containers and runs a new one from the image of
+// the given revision
+func (d *Docker) Replace(p *Project, rev Revision) error {
 	if err := d.cleanContainers(p); err != nil {
 		return err
 	}
@@ -63,6 +82,80 @@ func (d *Docker) Deploy(p *Project, rev Revision, dockerfile *Dockerfile, output
 	return d.Run(p, rev)
 }
 
+func (d *Docker) RunPreDeploy(p *Project, rev Revision, output io.Writer) error {
+	return d.runHook("Pre-deploy", p, p.PreDeployCommand, rev, output)
+}
+
+func (d *Docker) RunPostDeploy(p *Project, rev Revision, output io.Writer) error {
+	return d.runHook("Post-deploy", p, p.PostDeployCommand, rev, output)
+}
+
+func (d *Docker) runHook(name string, p *Project, command string, rev Revision, output io.Writer) error {
+	if command == "" {
+		return nil
+	}
+
+	Info("Running "+name+" command", "project", p, "command", command, "end-point", d.endPoint)
+	code, err := d.RunCommand(p, p.GetImageName(rev), command, output)
+	if err != nil {
+		return err
+	}
+
+	if code != 0 {
+		return fmt.Errorf("%s command %q failed with exit code %d", name, command, code)
+	}
+
+	return nil
+}
+
+// RunCommand runs the command in a temporary container created from the given
+// image, with the same configuration as the project containers, the output
+// is written to the given writer and the container is removed at the end.
+func (d *Docker) RunCommand(p *Project, image ImageID, command string, output io.Writer) (int, error) {
+	if err := d.ensureNetwork(); err != nil {
+		return -1, err
+	}
+
+	Debug("Creating one-off container", "project", p, "image", image, "end-point", d.endPoint)
+	c, err := d.createOneOffContainer(p, image, command)
+	if err != nil {
+		return -1, err
+	}
+
+	defer func() {
+		ropts := docker.RemoveContainerOptions{ID: c.ID, Force: true}
+		if err := d.client.RemoveContainer(ropts); err != nil {
+			Error("Unable to remove one-off container", "project", p, "container", c.GetShortID(), "end-point", d.endPoint)
+		}
+	}()
+
+	hc, err := d.formatHostConfig(p)
+	if err != nil {
+		return -1, err
+	}
+
+	hc.PortBindings = nil
+	hc.RestartPolicy = docker.NeverRestart()
+	if err := d.client.StartContainer(c.ID, hc); err != nil {
+		return -1, err
+	}
+
+	err = d.client.Logs(docker.LogsOptions{
+		Container:    c.ID,
+		OutputStream: output,
+		ErrorStream:  output,
+		Follow:       true,
+		Stdout:       true,
+		Stderr:       true,
+	})
+
+	if err != nil {
+		return -1, err
+	}
+
+	return d.client.WaitContainer(c.ID)
+}
+
 func (d *Docker) Clean(p *Project) error {
 	if err := d.cleanContainers(p); err != nil {
 		return err
@@ -181,6 +274,21 @@ func (d *Docker) ListContainers(p *Project) ([]*Container, error) {
 	return r, nil
 }
 
+func (d *Docker) getRunningContainer(p *Project) (*Container, error) {
+	l, err := d.ListContainers(p)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := len(l) - 1; i >= 0; i-- {
+		if l[i].IsRunning() {
+			return l[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("No running container at %q", d.endPoint)
+}
+
 func (d *Docker) ListImages(p *Project) ([]*Image, error) {
 	Debug("Retrieving current images", "project", p, "end-point", d.endPoint)
 
@@ -216,7 +324,7 @@ func (d *Docker) BuildImage(
 		return err
 	}
 
-	image := d.getImageName(p, rev)
+	image := p.GetImageName(rev)
 	opts := docker.BuildImageOptions{
 		Name:           string(image),
 		NoCache:        p.NoCache,
@@ -249,15 +357,25 @@ func (d *Docker) tagImage(image ImageID) error {
 }
 
 func (d *Docker) Run(p *Project, rev Revision) error {
-	Debug("Creating container from image", "project", p, "revision", rev, "end-point", d.endPoint)
-	c, err := d.createContainer(p, d.getImageName(p, rev))
+	return d.RunImage(p, p.GetImageName(rev))
+}
+
+// RunImage creates and starts a new container of the project from the given
+// image
+func (d *Docker) RunImage(p *Project, image ImageID) error {
+	if err := d.ensureNetwork(); err != nil {
+		return err
+	}
+
+	Debug("Creating container from image", "project", p, "image", image, "end-point", d.endPoint)
+	c, err := d.createContainer(p, image)
 	if err != nil {
 		return err
 	}
 
 	Info("Running new container",
 		"project", p,
-		"revision", rev.GetShort(),
+		"revision", image.GetRevisionString(),
 		"container", c.GetShortID(),
 		"end-point", d.endPoint,
 	)
@@ -266,26 +384,92 @@ func (d *Docker) Run(p *Project, rev Revision) error {
 		return err
 	}
 
+	if d.hasNetwork() {
+		return nil
+	}
+
 	return d.restartLinkedContainers(p)
 }
 
-func (d *Docker) getImageName(p *Project, rev Revision) ImageID {
-	c := rev.String()
-	if p.UseShortRevisions {
-		c = rev.GetShort()
+func (d *Docker) hasNetwork() bool {
+	return d.env != nil && d.env.Network != ""
+}
+
+func (d *Docker) ensureNetwork() error {
+	if !d.hasNetwork() {
+		return nil
 	}
 
-	return ImageID(fmt.Sprintf("%s:%s", p.Name, c))
+	l, err := d.client.ListNetworks()
+	if err != nil {
+		return err
+	}
+
+	for _, n := range l {
+		if n.Name == d.env.Network {
+			return nil
+		}
+	}
+
+	Info("Creating network", "network", d.env.Network, "end-point", d.endPoint)
+	_, err = d.client.CreateNetwork(docker.CreateNetworkOptions{
+		Name:           d.env.Network,
+		Driver:         "bridge",
+		CheckDuplicate: true,
+	})
+
+	return err
 }
 
 func (d *Docker) createContainer(p *Project, image ImageID) (*Container, error) {
-	c, err := d.client.CreateContainer(docker.CreateContainerOptions{
+	opts := docker.CreateContainerOptions{
 		Name: p.Name,
 		Config: &docker.Config{
 			Image: string(image),
+			Env:   p.Env,
 		},
-	})
+	}
+
+	if d.hasNetwork() {
+		opts.HostConfig = &docker.HostConfig{NetworkMode: d.env.Network}
+		opts.NetworkingConfig = &docker.NetworkingConfig{
+			EndpointsConfig: map[string]*docker.EndpointConfig{
+				d.env.Network: {
+					Aliases: d.formatNetworkAliases(p),
+					Links:   d.formatExternalLinks(p.Links),
+				},
+			},
+		}
+	}
+
+	c, err := d.client.CreateContainer(opts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Container{Image: image, APIContainers: docker.APIContainers{ID: c.ID}}, nil
+}
+
+func (d *Docker) createOneOffContainer(p *Project, image ImageID, command string) (*Container, error) {
+	opts := docker.CreateContainerOptions{
+		Config: &docker.Config{
+			Image: string(image),
+			Env:   p.Env,
+			Cmd:   []string{"/bin/sh", "-c", command},
+		},
+	}
 
+	if d.hasNetwork() {
+		opts.HostConfig = &docker.HostConfig{NetworkMode: d.env.Network}
+		opts.NetworkingConfig = &docker.NetworkingConfig{
+			EndpointsConfig: map[string]*docker.EndpointConfig{
+				d.env.Network: {Links: d.formatExternalLinks(p.Links)},
+			},
+		}
+	}
+
+	c, err := d.client.CreateContainer(opts)
 	if err != nil {
 		return nil, err
 	}
@@ -294,23 +478,46 @@ func (d *Docker) createContainer(p *Project, image ImageID) (*Container, error)
 }
 
 func (d *Docker) startContainer(p *Project, c *Container) error {
-	ports, err := d.formatPorts(p.Ports)
+	hc, err := d.formatHostConfig(p)
 	if err != nil {
 		return err
 	}
 
+	return d.client.StartContainer(c.ID, hc)
+}
+
+func (d *Docker) formatHostConfig(p *Project) (*docker.HostConfig, error) {
+	ports, err := d.formatPorts(p.Ports)
+	if err != nil {
+		return nil, err
+	}
+
 	restartPolicy, err := d.formatRestartPolicy(p.Restart)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	memory, err := ParseMemory(p.Memory)
+	if err != nil {
+		return nil, err
 	}
 
-	return d.client.StartContainer(c.ID, &docker.HostConfig{
+	hc := &docker.HostConfig{
 		PortBindings:  ports,
 		RestartPolicy: restartPolicy,
-		Links:         d.formatLinks(p.Links),
 		VolumesFrom:   p.VolumesFrom,
 		Binds:         p.Binds,
-	})
+		Memory:        memory,
+		CPUShares:     p.CPUShares,
+	}
+
+	if d.hasNetwork() {
+		hc.NetworkMode = d.env.Network
+	} else {
+		hc.Links = d.formatLinks(p.Links)
+	}
+
+	return hc, nil
 }
 
 func (d *Docker) formatLinks(links map[string]*Link) []string {
@@ -322,6 +529,39 @@ func (d *Docker) formatLinks(links map[string]*Link) []string {
 	return r
 }
 
+// formatExternalLinks returns the links to containers not managed by
+// dockership, the links to projects are resolved using network aliases
+func (d *Docker) formatExternalLinks(links map[string]*Link) []string {
+	var r []string
+	for _, link := range links {
+		if link.Project == nil {
+			r = append(r, link.String())
+		}
+	}
+
+	sort.Strings(r)
+	return r
+}
+
+// formatNetworkAliases returns the project name and every alias used by the
+// projects linking to it
+func (d *Docker) formatNetworkAliases(p *Project) []string {
+	r := []string{p.Name}
+	seen := map[string]bool{p.Name: true}
+	for _, linked := range p.LinkedBy {
+		link, ok := linked.Links[p.Name]
+		if !ok || seen[link.Alias] {
+			continue
+		}
+
+		seen[link.Alias] = true
+		r = append(r, link.Alias)
+	}
+
+	sort.Strings(r[1:])
+	return r
+}
+
 func (d *Docker) formatRestartPolicy(restart string) (policy docker.RestartPolicy, err error) {
 	values := strings.SplitN(restart, ":", 2)
 	if values[0] == "no" || restart == "" {
@@ -336,9 +576,12 @@ func (d *Docker) formatRestartPolicy(restart string) (policy docker.RestartPolic
 
 	if values[0] == "on-failure" {
 		var maxRetry int
-		maxRetry, err = strconv.Atoi(values[1])
-		if err != nil {
-			return
+		if len(values) == 2 {
+			maxRetry, err = strconv.Atoi(values[1])
+			if err != nil {
+				err = fmt.Errorf("Malformed restart policy %q", restart)
+				return
+			}
 		}
 
 		policy = docker.RestartOnFailure(maxRetry)
@@ -2,6 +2,7 @@ package core
 
 import (
 	"io"
+	"sort"
 	"sync"
 )
 
@@ -29,13 +30,71 @@ func NewDockerGroup(environment *Environment) (*DockerGroup, error) {
 }
 
 func (d *DockerGroup) Deploy(p *Project, rev Revision, dockerfile *Dockerfile, output io.Writer, force bool) []error {
+	return d.DeployWithReport(p, rev, dockerfile, output, force).Errors
+}
+
+// DeployWithReport deploys the revision as Deploy does, reporting how far the
+// deploy went at each docker end point
+func (d *DockerGroup) DeployWithReport(p *Project, rev Revision, dockerfile *Dockerfile, output io.Writer, force bool) *DeployReport {
+	p = p.ForEnvironment(d.environment.Name)
+	output = MultiEndPointWriter(output)
 	Info("Deploying dockerfile", "project", p, "revision", rev, "end-points", len(d.dockers))
-	return d.batchErrorResult(func(docker *Docker) interface{} {
-		return &errorResult{err: docker.Deploy(p, rev, dockerfile, output, force)}
+	r := &DeployReport{Revision: rev, EndPoints: make(map[string]*EndPointReport, 0)}
+	for endPoint := range d.dockers {
+		r.EndPoints[endPoint] = &EndPointReport{}
+	}
+
+	if len(d.dockers) == 0 {
+		return r
+	}
+
+	built := d.batchEndPointResult(func(docker *Docker) error {
+		return docker.Build(p, rev, dockerfile, forEndPoint(output, docker.endPoint))
+	})
+
+	if !r.add(built, func(e *EndPointReport) { e.Built = true }) {
+		return r
+	}
+
+	hook := d.getHookDocker()
+	if err := hook.RunPreDeploy(p, rev, forEndPoint(output, hook.endPoint)); err != nil {
+		r.add(map[string]error{hook.endPoint: err}, nil)
+		return r
+	}
+
+	replaced := d.batchEndPointResult(func(docker *Docker) error {
+		return docker.Replace(p, rev)
 	})
+
+	if !r.add(replaced, func(e *EndPointReport) { e.Replaced = true }) {
+		return r
+	}
+
+	if err := hook.RunPostDeploy(p, rev, forEndPoint(output, hook.endPoint)); err != nil {
+		r.add(map[string]error{hook.endPoint: err}, nil)
+	}
+
+	return r
+}
+
+func (d *DockerGroup) getHookDocker() *Docker {
+	if d.environment != nil {
+		if docker, ok := d.dockers[d.environment.GetHookEndPoint()]; ok {
+			return docker
+		}
+	}
+
+	var endPoints []string
+	for endPoint := range d.dockers {
+		endPoints = append(endPoints, endPoint)
+	}
+
+	sort.Strings(endPoints)
+	return d.dockers[endPoints[0]]
 }
 
 func (d *DockerGroup) Clean(p *Project) []error {
+	p = p.ForEnvironment(d.environment.Name)
 	Info("Cleaning containers", "project", p, "end-points", len(d.dockers))
 	return d.batchErrorResult(func(docker *Docker) interface{} {
 		return &errorResult{err: docker.Clean(p)}
@@ -91,6 +150,8 @@ func (d *DockerGroup) ListImages(p *Project) ([]*Image, []error) {
 }
 
 func (d *DockerGroup) BuildImage(p *Project, rev Revision, dockerfile *Dockerfile, output io.Writer) []error {
+	p = p.ForEnvironment(d.environment.Name)
+	output = MultiEndPointWriter(output)
 	Info("Building image", "project", p, "revision", rev, "end-points", len(d.dockers))
 	return d.batchErrorResult(func(docker *Docker) interface{} {
 		return &errorResult{err: docker.BuildImage(p, rev, dockerfile, output)}
@@ -98,6 +159,7 @@ func (d *DockerGroup) BuildImage(p *Project, rev Revision, dockerfile *Dockerfil
 }
 
 func (d *DockerGroup) Run(p *Project, rev Revision) []error {
+	p = p.ForEnvironment(d.environment.Name)
 	return d.batchErrorResult(func(docker *Docker) interface{} {
 		return &errorResult{err: docker.Run(p, rev)}
 	})
@@ -105,6 +167,23 @@ func (d *DockerGroup) Run(p *Project, rev Revision) []error {
 
 type errorResult struct{ err error }
 
+type endPointResult struct {
+	endPoint string
+	err      error
+}
+
+func (d *DockerGroup) batchEndPointResult(f func(docker *Docker) error) map[string]error {
+	r := make(map[string]error, 0)
+	for _, e := range d.batchInterfaceResult(func(docker *Docker) interface{} {
+		return &endPointResult{docker.endPoint, f(docker)}
+	}) {
+		result := e.(*endPointResult)
+		r[result.endPoint] = result.err
+	}
+
+	return r
+}
+
 func (d *DockerGroup) batchErrorResult(f func(docker *Docker) interface{}) []error {
 	var r []error
 	for _, e := range d.batchInterfaceResult(f) {
@@ -137,3 +216,46 @@ func (d *DockerGroup) batchInterfaceResult(f func(docker *Docker) interface{}) [
 
 	return r
 }
+
+type DeployReport struct {
+	Ref       string
+	Revision  Revision
+	EndPoints map[string]*EndPointReport
+	Errors    []error
+}
+
+type EndPointReport struct {
+	Built    bool
+	Replaced bool
+	Error    string `json:",omitempty"`
+}
+
+// add records the result of a deploy phase, the succeeded end points are
+// updated with the given function, returns false if any end point failed
+func (r *DeployReport) add(results map[string]error, succeeded func(*EndPointReport)) bool {
+	var endPoints []string
+	for endPoint := range results {
+		endPoints = append(endPoints, endPoint)
+	}
+
+	sort.Strings(endPoints)
+
+	ok := true
+	for _, endPoint := range endPoints {
+		e, found := r.EndPoints[endPoint]
+		if !found {
+			e = &EndPointReport{}
+			r.EndPoints[endPoint] = e
+		}
+
+		if err := results[endPoint]; err != nil {
+			ok = false
+			e.Error = err.Error()
+			r.Errors = append(r.Errors, err)
+		} else if succeeded != nil {
+			succeeded(e)
+		}
+	}
+
+	return ok
+}
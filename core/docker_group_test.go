@@ -2,6 +2,7 @@ package core
 
 import (
 	"bytes"
+	"errors"
 
 	"github.com/fsouza/go-dockerclient/testing"
 	. "gopkg.in/check.v1"
@@ -91,3 +92,27 @@ func (s *CoreSuite) TestDockerGroup_DeployListContainersAndListImages(c *C) {
 		c.Assert(r.RepoTags, HasLen, 2)
 	}
 }
+
+func (s *CoreSuite) TestDeployReport_add(c *C) {
+	r := &DeployReport{EndPoints: map[string]*EndPointReport{
+		"tcp://foo": &EndPointReport{},
+		"tcp://bar": &EndPointReport{},
+	}}
+
+	ok := r.add(map[string]error{"tcp://foo": nil, "tcp://bar": nil}, func(e *EndPointReport) {
+		e.Built = true
+	})
+
+	c.Assert(ok, Equals, true)
+	c.Assert(r.EndPoints["tcp://foo"].Built, Equals, true)
+
+	ok = r.add(map[string]error{"tcp://foo": nil, "tcp://bar": errors.New("qux")}, func(e *EndPointReport) {
+		e.Replaced = true
+	})
+
+	c.Assert(ok, Equals, false)
+	c.Assert(r.EndPoints["tcp://foo"].Replaced, Equals, true)
+	c.Assert(r.EndPoints["tcp://bar"].Replaced, Equals, false)
+	c.Assert(r.EndPoints["tcp://bar"].Error, Equals, "qux")
+	c.Assert(r.Errors, HasLen, 1)
+}
@@ -42,6 +42,31 @@ func (s *CoreSuite) TestDocker_Deploy(c *C) {
 	c.Assert(string(input.Bytes()), HasLen, 51)
 }
 
+func (s *CoreSuite) TestDocker_DeployPreDeployFails(c *C) {
+	m, _ := testing.NewServer("127.0.0.1:0", nil, nil)
+	m.CustomHandler("/containers/{id:.*}/logs", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	m.CustomHandler("/containers/{id:.*}/wait", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"StatusCode":1}`))
+	}))
+
+	p := &Project{
+		Name:             "foo",
+		Repository:       "git@github.com:foo/bar.git",
+		PreDeployCommand: "rake db:migrate",
+	}
+
+	d, _ := NewDocker(m.URL(), nil)
+	err := d.Deploy(p, Revision{"foo": "bar"}, &Dockerfile{content: []byte("FROM base\n")}, ioutil.Discard, false)
+	c.Assert(err, ErrorMatches, `Pre-deploy command "rake db:migrate" failed with exit code 1`)
+
+	l, _ := d.ListContainers(p)
+	c.Assert(l, HasLen, 0)
+}
+
 func (s *CoreSuite) TestDocker_BuildImage(c *C) {
 	var requests []*http.Request
 	files := make(map[string]string, 0)
@@ -177,6 +202,66 @@ func (s *CoreSuite) TestDocker_RunLinked(c *C) {
 	return
 }
 
+func (s *CoreSuite) TestDocker_RunWithNetwork(c *C) {
+	m, _ := testing.NewServer("127.0.0.1:0", nil, nil)
+	d, _ := docker.NewClient(m.URL())
+
+	p := &Project{Name: "foo", Repository: "git@github.com:foo/bar.git", UseShortRevisions: true}
+	buildImage(d, "foo:qux")
+
+	dc, err := NewDocker(m.URL(), &Environment{Name: "live", Network: "dockership"})
+	c.Assert(err, Equals, nil)
+
+	err = dc.Run(p, Revision{"foo/bar": "qux"})
+	c.Assert(err, Equals, nil)
+
+	l, err := d.ListNetworks()
+	c.Assert(err, Equals, nil)
+
+	var found bool
+	for _, n := range l {
+		if n.Name == "dockership" {
+			found = true
+		}
+	}
+
+	c.Assert(found, Equals, true)
+
+	err = dc.ensureNetwork()
+	c.Assert(err, Equals, nil)
+
+	after, _ := d.ListNetworks()
+	c.Assert(after, HasLen, len(l))
+}
+
+func (s *CoreSuite) TestDocker_formatNetworkAliases(c *C) {
+	mysql := &Project{Name: "mysql"}
+	foo := &Project{Name: "foo", Links: map[string]*Link{
+		"mysql": &Link{Project: mysql, Container: "mysql", Alias: "db"},
+	}}
+	bar := &Project{Name: "bar", Links: map[string]*Link{
+		"mysql": &Link{Project: mysql, Container: "mysql", Alias: "database"},
+	}}
+	qux := &Project{Name: "qux", Links: map[string]*Link{
+		"mysql": &Link{Project: mysql, Container: "mysql", Alias: "db"},
+	}}
+
+	mysql.LinkedBy = []*Project{foo, bar, qux}
+
+	d, _ := NewDocker("tcp://foo", &Environment{Name: "foo", Network: "foo"})
+	c.Assert(d.formatNetworkAliases(mysql), DeepEquals, []string{"mysql", "database", "db"})
+}
+
+func (s *CoreSuite) TestDocker_formatExternalLinks(c *C) {
+	links := map[string]*Link{
+		"qux":   &Link{Project: &Project{Name: "qux"}, Container: "qux", Alias: "qux"},
+		"mysql": &Link{Container: "mysql", Alias: "db"},
+	}
+
+	d, _ := NewDocker("tcp://foo", &Environment{Name: "foo", Network: "foo"})
+	c.Assert(d.formatExternalLinks(links), DeepEquals, []string{"mysql:db"})
+}
+
 func (s *CoreSuite) TestDocker_Clean(c *C) {
 	if !*slowFlag {
 		c.Skip("-slow not provided")
@@ -322,3 +407,25 @@ func writeRandomFile(content string) string {
 
 	return f.Name()
 }
+
+func (s *CoreSuite) TestCheckPort(c *C) {
+	c.Assert(CheckPort("0.0.0.0:80:8080/tcp"), IsNil)
+	c.Assert(CheckPort("0.0.0.0:80:8080/tcp@live"), IsNil)
+	c.Assert(CheckPort("80:8080"), NotNil)
+	c.Assert(CheckPort("0.0.0.0:foo:8080/tcp"), NotNil)
+}
+
+func (s *CoreSuite) TestCheckRestart(c *C) {
+	c.Assert(CheckRestart(""), IsNil)
+	c.Assert(CheckRestart("always"), IsNil)
+	c.Assert(CheckRestart("on-failure"), IsNil)
+	c.Assert(CheckRestart("on-failure:3"), IsNil)
+	c.Assert(CheckRestart("on-failure:foo"), NotNil)
+	c.Assert(CheckRestart("sometimes"), NotNil)
+}
+
+func (s *CoreSuite) TestProject_HostPorts(c *C) {
+	p := &Project{Ports: []string{"0.0.0.0:80:8080/tcp", "1.2.3.4:53:53/udp@dev", "foo"}}
+	c.Assert(p.HostPorts(&Environment{Name: "live"}), DeepEquals, []string{"0.0.0.0:80/tcp"})
+	c.Assert(p.HostPorts(&Environment{Name: "dev"}), DeepEquals, []string{"0.0.0.0:80/tcp", "1.2.3.4:53/udp"})
+}
@@ -0,0 +1,229 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/fsouza/go-dockerclient"
+)
+
+type ConfigDiff struct {
+	Field    string
+	Expected string
+	Found    string
+}
+
+func (d *ConfigDiff) String() string {
+	return fmt.Sprintf("%s: expected %q, found %q", d.Field, d.Expected, d.Found)
+}
+
+// ContainerDrift holds the differences between a running container and the
+// container that would be started with the current configuration
+type ContainerDrift struct {
+	DockerEndPoint string
+	Container      string
+	Diffs          []*ConfigDiff
+}
+
+// DetectDrift inspects the running containers of the project at every docker
+// end point of the environment, the image is compared with the desired state
+// only if it is known
+func (p *Project) DetectDrift(e *Environment) ([]*ContainerDrift, []error) {
+	d, err := NewDockerGroup(e)
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	return d.DetectDrift(p)
+}
+
+type detectDriftResult struct {
+	drifts []*ContainerDrift
+	err    error
+}
+
+func (d *DockerGroup) DetectDrift(p *Project) ([]*ContainerDrift, []error) {
+	p = p.ForEnvironment(d.environment.Name)
+	var image ImageID
+	if s := States.Get(p, d.environment); s != nil {
+		image = s.Image
+	}
+
+	f := func(docker *Docker) interface{} {
+		l, err := docker.DetectDrift(p, image)
+		return &detectDriftResult{l, err}
+	}
+
+	var errors []error
+	var drifts []*ContainerDrift
+	for _, e := range d.batchInterfaceResult(f) {
+		r := e.(*detectDriftResult)
+		if r.err != nil {
+			errors = append(errors, r.err)
+		}
+
+		drifts = append(drifts, r.drifts...)
+	}
+
+	return drifts, errors
+}
+
+func (d *Docker) DetectDrift(p *Project, image ImageID) ([]*ContainerDrift, error) {
+	l, err := d.ListContainers(p)
+	if err != nil {
+		return nil, err
+	}
+
+	var r []*ContainerDrift
+	for _, c := range l {
+		if !c.IsRunning() {
+			continue
+		}
+
+		container, err := d.client.InspectContainer(c.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		diffs, err := d.diffContainer(p, image, container)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(diffs) != 0 {
+			r = append(r, &ContainerDrift{
+				DockerEndPoint: d.endPoint,
+				Container:      c.GetShortID(),
+				Diffs:          diffs,
+			})
+		}
+	}
+
+	return r, nil
+}
+
+func (d *Docker) diffContainer(p *Project, image ImageID, c *docker.Container) ([]*ConfigDiff, error) {
+	expected, err := d.formatHostConfig(p)
+	if err != nil {
+		return nil, err
+	}
+
+	found := c.HostConfig
+	if found == nil {
+		found = &docker.HostConfig{}
+	}
+
+	var r []*ConfigDiff
+	add := func(field, expected, found string) {
+		if expected != found {
+			r = append(r, &ConfigDiff{Field: field, Expected: expected, Found: found})
+		}
+	}
+
+	if image != "" && c.Config != nil {
+		add("Image", string(image), c.Config.Image)
+	}
+
+	add("Ports", formatPortBindings(expected.PortBindings), formatPortBindings(found.PortBindings))
+	add("Binds", joinSorted(expected.Binds), joinSorted(found.Binds))
+	add("VolumesFrom", joinSorted(expected.VolumesFrom), joinSorted(found.VolumesFrom))
+	add("Restart", formatRestart(expected.RestartPolicy), formatRestart(found.RestartPolicy))
+
+	if d.hasNetwork() {
+		add("Network", expected.NetworkMode, found.NetworkMode)
+	} else {
+		add("Links", joinSorted(expected.Links), joinSorted(normalizeLinks(found.Links)))
+	}
+
+	var env []string
+	if c.Config != nil {
+		env = c.Config.Env
+	}
+
+	for _, v := range p.Env {
+		if !strings.Contains(v, "=") {
+			continue
+		}
+
+		if found := findEnv(env, v); found != v {
+			r = append(r, &ConfigDiff{
+				Field:    "Env " + strings.SplitN(v, "=", 2)[0],
+				Expected: maskEnv(v),
+				Found:    maskEnv(found),
+			})
+		}
+	}
+
+	return r, nil
+}
+
+func formatPortBindings(ports map[docker.Port][]docker.PortBinding) string {
+	var r []string
+	for guest, bindings := range ports {
+		if guest == "" {
+			continue
+		}
+
+		for _, b := range bindings {
+			r = append(r, fmt.Sprintf("%s:%s->%s", b.HostIP, b.HostPort, guest))
+		}
+	}
+
+	return joinSorted(r)
+}
+
+func formatRestart(policy docker.RestartPolicy) string {
+	switch policy.Name {
+	case "", "no":
+		return "no"
+	case "on-failure":
+		return fmt.Sprintf("on-failure:%d", policy.MaximumRetryCount)
+	}
+
+	return policy.Name
+}
+
+// normalizeLinks converts the links returned by docker, /container:/name/alias,
+// to the container:alias format
+func normalizeLinks(links []string) []string {
+	var r []string
+	for _, l := range links {
+		parts := strings.SplitN(l, ":", 2)
+		if len(parts) != 2 {
+			r = append(r, l)
+			continue
+		}
+
+		alias := parts[1][strings.LastIndex(parts[1], "/")+1:]
+		r = append(r, fmt.Sprintf("%s:%s", strings.TrimPrefix(parts[0], "/"), alias))
+	}
+
+	return r
+}
+
+func findEnv(env []string, variable string) string {
+	name := strings.SplitN(variable, "=", 2)[0] + "="
+	for _, v := range env {
+		if strings.HasPrefix(v, name) {
+			return v
+		}
+	}
+
+	return ""
+}
+
+// maskEnv hides the value of the environment variable, it may be a secret
+func maskEnv(variable string) string {
+	if variable == "" {
+		return ""
+	}
+
+	return strings.SplitN(variable, "=", 2)[0] + "=***"
+}
+
+func joinSorted(l []string) string {
+	s := append([]string{}, l...)
+	sort.Strings(s)
+	return strings.Join(s, ", ")
+}
@@ -0,0 +1,64 @@
+package core
+
+import (
+	"github.com/fsouza/go-dockerclient"
+	. "gopkg.in/check.v1"
+)
+
+func (s *CoreSuite) TestDocker_diffContainer(c *C) {
+	p := &Project{
+		Name:    "foo",
+		Ports:   []string{"0.0.0.0:8080:80/tcp"},
+		Binds:   []string{"/tmp:/tmp"},
+		Restart: "always",
+		Env:     []string{"FOO=bar", "QUX=baz", "HOME"},
+		Links: map[string]*Link{
+			"bar": &Link{Container: "bar", Alias: "db"},
+		},
+	}
+
+	d := &Docker{endPoint: "tcp://foo"}
+	diffs, err := d.diffContainer(p, ImageID("foo:qux"), &docker.Container{
+		Config: &docker.Config{
+			Image: "foo:bar",
+			Env:   []string{"PATH=/bin", "FOO=bar", "QUX=qux"},
+		},
+		HostConfig: &docker.HostConfig{
+			PortBindings: map[docker.Port][]docker.PortBinding{
+				"80/tcp": []docker.PortBinding{{HostIP: "0.0.0.0", HostPort: "8080"}},
+			},
+			Binds:         []string{"/tmp:/tmp"},
+			RestartPolicy: docker.NeverRestart(),
+			Links:         []string{"/bar:/foo/db"},
+		},
+	})
+
+	c.Assert(err, IsNil)
+	c.Assert(diffs, HasLen, 3)
+	c.Assert(diffs[0].Field, Equals, "Image")
+	c.Assert(diffs[0].Expected, Equals, "foo:qux")
+	c.Assert(diffs[0].Found, Equals, "foo:bar")
+	c.Assert(diffs[1].Field, Equals, "Restart")
+	c.Assert(diffs[1].Expected, Equals, "always")
+	c.Assert(diffs[1].Found, Equals, "no")
+	c.Assert(diffs[2].Field, Equals, "Env QUX")
+	c.Assert(diffs[2].Expected, Equals, "QUX=***")
+	c.Assert(diffs[2].Found, Equals, "QUX=***")
+}
+
+func (s *CoreSuite) TestDocker_diffContainerWithoutImage(c *C) {
+	p := &Project{Name: "foo"}
+
+	d := &Docker{endPoint: "tcp://foo"}
+	diffs, err := d.diffContainer(p, "", &docker.Container{
+		Config:     &docker.Config{Image: "foo:bar"},
+		HostConfig: &docker.HostConfig{},
+	})
+
+	c.Assert(err, IsNil)
+	c.Assert(diffs, HasLen, 0)
+}
+
+func (s *CoreSuite) TestNormalizeLinks(c *C) {
+	c.Assert(normalizeLinks([]string{"/mysql:/foo/db", "bar:qux"}), DeepEquals, []string{"mysql:db", "bar:qux"})
+}
@@ -3,6 +3,7 @@ package core
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"golang.org/x/net/context"
 
@@ -46,3 +47,84 @@ func (e *Etcd) Get(key string) (string, error) {
 
 	return r.Node.Value, err
 }
+
+// List returns the values of every key under the directory, recursively, a
+// missing directory is returned as empty
+func (e *Etcd) List(dir string) (map[string]string, error) {
+	r, err := e.kAPI.Get(context.Background(), dir, &etcd.GetOptions{Recursive: true})
+	if err != nil {
+		if eerr, ok := err.(etcd.Error); ok && eerr.Code == etcd.ErrorCodeKeyNotFound {
+			return map[string]string{}, nil
+		}
+
+		return nil, fmt.Errorf("Error retrieving %q: %s", dir, err)
+	}
+
+	values := make(map[string]string, 0)
+	addNodeValues(values, r.Node)
+	return values, nil
+}
+
+func addNodeValues(values map[string]string, n *etcd.Node) {
+	if !n.Dir {
+		values[n.Key] = n.Value
+		return
+	}
+
+	for _, child := range n.Nodes {
+		addNodeValues(values, child)
+	}
+}
+
+func (e *Etcd) Set(key, value string) error {
+	if _, err := e.kAPI.Set(context.Background(), key, value, nil); err != nil {
+		return fmt.Errorf("Error setting %q: %s", key, err)
+	}
+
+	return nil
+}
+
+func (e *Etcd) Delete(key string) error {
+	if _, err := e.kAPI.Delete(context.Background(), key, nil); err != nil {
+		return fmt.Errorf("Error deleting %q: %s", key, err)
+	}
+
+	return nil
+}
+
+// Watch calls fn after every change under the key until stop is closed, if
+// the watch is lost it is restarted and fn is called, since some changes may
+// have been missed
+func (e *Etcd) Watch(key string, stop chan bool, fn func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-stop
+		cancel()
+	}()
+
+	backoff := WatcherMinBackoff
+	for {
+		w := e.kAPI.Watcher(key, &etcd.WatcherOptions{Recursive: true})
+		for {
+			if _, err := w.Next(ctx); err != nil {
+				Warning("Etcd watch lost", "key", key, "error", err, "retry", backoff)
+				break
+			}
+
+			backoff = WatcherMinBackoff
+			fn()
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-time.After(backoff):
+		}
+
+		if backoff *= 2; backoff > WatcherMaxBackoff {
+			backoff = WatcherMaxBackoff
+		}
+
+		fn()
+	}
+}
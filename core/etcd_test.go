@@ -27,6 +27,17 @@ func (_ *CoreSuite) TestEtcd_GetDir(c *C) {
 	c.Assert(err, ErrorMatches, "Key \"dir\" is a directory")
 }
 
+func (_ *CoreSuite) TestEtcd_List(c *C) {
+	go startEtcdMockServer()
+
+	e, err := NewEtcd([]string{"http://127.0.0.1:3000/"})
+	c.Assert(err, IsNil)
+
+	r, err := e.List("bar")
+	c.Assert(err, Equals, nil)
+	c.Assert(r, DeepEquals, map[string]string{"/bar/foo": "barfoobarfoo", "/bar/qux/baz": "baz"})
+}
+
 type reponseHandler struct {
 	data string
 }
@@ -46,6 +57,13 @@ func startEtcdMockServer() {
 		"{\"action\":\"get\",\"node\":{\"key\":\"/mykey\",\"value\":\"foofoo\",\"modifiedIndex\":3,\"createdIndex\":3}}",
 	})
 
+	mux.Handle("/v2/keys/bar", &reponseHandler{
+		"{\"action\":\"get\",\"node\":{\"key\":\"/bar\",\"dir\":true,\"nodes\":[" +
+			"{\"key\":\"/bar/foo\",\"value\":\"barfoobarfoo\",\"modifiedIndex\":3,\"createdIndex\":3}," +
+			"{\"key\":\"/bar/qux\",\"dir\":true,\"nodes\":[{\"key\":\"/bar/qux/baz\",\"value\":\"baz\"}]}" +
+			"],\"modifiedIndex\":3,\"createdIndex\":3}}",
+	})
+
 	mux.Handle("/v2/keys/bar/foo", &reponseHandler{
 		"{\"action\":\"get\",\"node\":{\"key\":\"/mykey\",\"value\":\"barfoobarfoo\",\"modifiedIndex\":3,\"createdIndex\":3}}",
 	})
@@ -0,0 +1,131 @@
+package core
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/fsouza/go-dockerclient"
+)
+
+var DefaultExecCommand = []string{"/bin/sh"}
+
+// Exec is an interactive command, with a TTY, running inside of a project
+// container.
+type Exec struct {
+	ID        string
+	Project   *Project
+	Container *Container
+	EndPoint  string
+	Command   []string
+	docker    *Docker
+}
+
+// NewExec prepares a new Exec at the running container of the project in the
+// given docker end point of the environment, if the end point is empty the
+// HookEndPoint is used.
+func (p *Project) NewExec(environment, endPoint string, cmd []string) (*Exec, error) {
+	p = p.ForEnvironment(environment)
+	e, err := p.getEnvironment(environment)
+	if err != nil {
+		return nil, err
+	}
+
+	if endPoint == "" {
+		endPoint = e.GetHookEndPoint()
+	}
+
+	if len(cmd) == 0 {
+		cmd = DefaultExecCommand
+	}
+
+	d, err := p.getDocker(e, endPoint)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := d.getRunningContainer(p)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.CreateExec(p, c, cmd)
+}
+
+func (d *Docker) CreateExec(p *Project, c *Container, cmd []string) (*Exec, error) {
+	Debug("Creating exec", "project", p, "container", c.GetShortID(), "end-point", d.endPoint)
+	e, err := d.client.CreateExec(docker.CreateExecOptions{
+		Container:    c.ID,
+		Cmd:          cmd,
+		AttachStdin:  true,
+		AttachStdout: true,
+		AttachStderr: true,
+		Tty:          true,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Exec{
+		ID:        e.ID,
+		Project:   p,
+		Container: c,
+		EndPoint:  d.endPoint,
+		Command:   cmd,
+		docker:    d,
+	}, nil
+}
+
+// Start runs the exec relaying the input to the TTY and the TTY to the output,
+// it blocks until the command finishes and returns the exit code. If height
+// and width are given the TTY is resized once the exec is running.
+func (e *Exec) Start(input io.Reader, output io.Writer, height, width int) (int, error) {
+	success := make(chan struct{})
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		select {
+		case <-success:
+		case <-done:
+			return
+		}
+
+		success <- struct{}{}
+		if height == 0 || width == 0 {
+			return
+		}
+
+		if err := e.Resize(height, width); err != nil {
+			Warning("Unable to resize exec", "container", e, "err", err)
+		}
+	}()
+
+	err := e.docker.client.StartExec(e.ID, docker.StartExecOptions{
+		InputStream:  input,
+		OutputStream: output,
+		ErrorStream:  output,
+		Tty:          true,
+		RawTerminal:  true,
+		Success:      success,
+	})
+
+	if err != nil {
+		return -1, err
+	}
+
+	i, err := e.docker.client.InspectExec(e.ID)
+	if err != nil {
+		return -1, err
+	}
+
+	return i.ExitCode, nil
+}
+
+func (e *Exec) Resize(height, width int) error {
+	return e.docker.client.ResizeExecTTY(e.ID, height, width)
+}
+
+func (e *Exec) String() string {
+	return fmt.Sprintf("%s@%s", e.Container.GetShortID(), e.EndPoint)
+}
@@ -0,0 +1,48 @@
+package core
+
+import (
+	"bytes"
+
+	"github.com/fsouza/go-dockerclient/testing"
+	. "gopkg.in/check.v1"
+)
+
+func (s *CoreSuite) TestProject_NewExec(c *C) {
+	m, _ := testing.NewServer("127.0.0.1:0", nil, nil)
+	e := &Environment{Name: "a", DockerEndPoints: []string{m.URL()}}
+	p := &Project{
+		Name:         "foo",
+		Repository:   "git@github.com:foo/bar.git",
+		Environments: map[string]*Environment{"a": e},
+	}
+
+	input := bytes.NewBuffer(nil)
+	d, _ := NewDocker(m.URL(), e)
+	err := d.Deploy(p, Revision{"foo": "bar"}, &Dockerfile{content: []byte("FROM base\n")}, input, false)
+	c.Assert(err, IsNil)
+
+	l, _ := d.ListContainers(p)
+	c.Assert(l, HasLen, 1)
+
+	exec, err := p.NewExec("a", "", nil)
+	c.Assert(err, IsNil)
+	c.Assert(exec.ID, Not(Equals), "")
+	c.Assert(exec.Command, DeepEquals, DefaultExecCommand)
+	c.Assert(exec.Container.ID, Equals, l[0].ID)
+	c.Assert(exec.EndPoint, Equals, m.URL())
+}
+
+func (s *CoreSuite) TestProject_NewExecUnknownEndPoint(c *C) {
+	e := &Environment{Name: "a", DockerEndPoints: []string{"tcp://foo"}}
+	p := &Project{
+		Name:         "foo",
+		Repository:   "git@github.com:foo/bar.git",
+		Environments: map[string]*Environment{"a": e},
+	}
+
+	_, err := p.NewExec("a", "tcp://bar", nil)
+	c.Assert(err, ErrorMatches, `Docker end point "tcp://bar" not defined in environment "a"`)
+
+	_, err = p.NewExec("b", "", nil)
+	c.Assert(err, ErrorMatches, `Environment "b" not defined in project "foo"`)
+}
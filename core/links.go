@@ -0,0 +1,103 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type LinkCycleError struct {
+	Projects []string
+}
+
+func (e *LinkCycleError) Error() string {
+	return fmt.Sprintf("Cyclic links between projects: %s", strings.Join(e.Projects, " -> "))
+}
+
+// SortByLinks returns the projects in deploy order, every project goes after
+// the projects it links to. Links to projects not present in the given list
+// are ignored.
+func SortByLinks(projects []*Project) ([]*Project, error) {
+	byName := make(map[string]*Project, len(projects))
+	names := make([]string, 0, len(projects))
+	for _, p := range projects {
+		byName[p.Name] = p
+		names = append(names, p.Name)
+	}
+
+	sort.Strings(names)
+
+	const (
+		visiting = iota + 1
+		visited
+	)
+
+	state := make(map[string]int, len(projects))
+	sorted := make([]*Project, 0, len(projects))
+	var path []string
+
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			return &LinkCycleError{Projects: append(cycleFrom(path, name), name)}
+		}
+
+		state[name] = visiting
+		path = append(path, name)
+
+		p := byName[name]
+		for _, linked := range p.getLinkedProjectNames() {
+			if _, ok := byName[linked]; !ok {
+				continue
+			}
+
+			if err := visit(linked); err != nil {
+				return err
+			}
+		}
+
+		path = path[:len(path)-1]
+		state[name] = visited
+		sorted = append(sorted, p)
+		return nil
+	}
+
+	for _, name := range names {
+		if err := visit(name); err != nil {
+			return nil, err
+		}
+	}
+
+	return sorted, nil
+}
+
+func cycleFrom(path []string, name string) []string {
+	for i, n := range path {
+		if n == name {
+			return append([]string{}, path[i:]...)
+		}
+	}
+
+	return []string{}
+}
+
+func (p *Project) getLinkedProjectNames() []string {
+	var r []string
+	if p.Links != nil {
+		for name, l := range p.Links {
+			if l.Project != nil {
+				r = append(r, name)
+			}
+		}
+	} else {
+		for _, l := range p.LinkNames {
+			r = append(r, l.GetProjectName())
+		}
+	}
+
+	sort.Strings(r)
+	return r
+}
@@ -0,0 +1,47 @@
+package core
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+func (s *CoreSuite) TestSortByLinks(c *C) {
+	mysql := &Project{Name: "mysql"}
+	backend := &Project{Name: "backend", LinkNames: []LinkDefinition{"mysql:db"}}
+	frontend := &Project{Name: "frontend", LinkNames: []LinkDefinition{
+		"backend:api", "redis:cache",
+	}}
+
+	r, err := SortByLinks([]*Project{frontend, backend, mysql})
+	c.Assert(err, IsNil)
+	c.Assert(r, HasLen, 3)
+	c.Assert(r[0].Name, Equals, "mysql")
+	c.Assert(r[1].Name, Equals, "backend")
+	c.Assert(r[2].Name, Equals, "frontend")
+}
+
+func (s *CoreSuite) TestSortByLinksSubset(c *C) {
+	backend := &Project{Name: "backend", LinkNames: []LinkDefinition{"mysql:db"}}
+	frontend := &Project{Name: "frontend", LinkNames: []LinkDefinition{"backend:api"}}
+
+	r, err := SortByLinks([]*Project{frontend, backend})
+	c.Assert(err, IsNil)
+	c.Assert(r, HasLen, 2)
+	c.Assert(r[0].Name, Equals, "backend")
+	c.Assert(r[1].Name, Equals, "frontend")
+}
+
+func (s *CoreSuite) TestSortByLinksCycle(c *C) {
+	a := &Project{Name: "a", LinkNames: []LinkDefinition{"b:b"}}
+	b := &Project{Name: "b", LinkNames: []LinkDefinition{"c:c"}}
+	cp := &Project{Name: "c", LinkNames: []LinkDefinition{"a:a"}}
+
+	_, err := SortByLinks([]*Project{a, b, cp})
+	c.Assert(err, ErrorMatches, "Cyclic links between projects: a -> b -> c -> a")
+}
+
+func (s *CoreSuite) TestSortByLinksSelf(c *C) {
+	a := &Project{Name: "a", LinkNames: []LinkDefinition{"a:a"}}
+
+	_, err := SortByLinks([]*Project{a})
+	c.Assert(err, ErrorMatches, "Cyclic links between projects: a -> a")
+}
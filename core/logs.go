@@ -0,0 +1,195 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/fsouza/go-dockerclient"
+)
+
+var ErrLogsClosed = errors.New("Logs stream closed")
+
+type LogsOptions struct {
+	// Context cancels the streaming, required with Follow to release the
+	// connections when the client goes away
+	Context context.Context
+	Follow  bool
+	Tail    string
+	Since   int64
+	Stdout  bool
+	Stderr  bool
+}
+
+type LogLine struct {
+	DockerEndPoint string
+	Container      string
+	Stream         string
+	Line           string
+}
+
+// LogHandler receives every log line, the streaming stops when returns an
+// error, it may be called concurrently.
+type LogHandler func(*LogLine) error
+
+// Logs retrieves the logs of every container of the project in the given
+// environment, with Follow the call blocks until the containers stop or the
+// handler returns an error.
+func (p *Project) Logs(environment string, opts LogsOptions, h LogHandler) []error {
+	e, err := p.getEnvironment(environment)
+	if err != nil {
+		return []error{err}
+	}
+
+	d, err := NewDockerGroup(e)
+	if err != nil {
+		return []error{err}
+	}
+
+	return d.Logs(p, opts, h)
+}
+
+func (d *DockerGroup) Logs(p *Project, opts LogsOptions, h LogHandler) []error {
+	var m sync.Mutex
+	safe := func(l *LogLine) error {
+		m.Lock()
+		defer m.Unlock()
+
+		return h(l)
+	}
+
+	return d.batchErrorResult(func(docker *Docker) interface{} {
+		return &errorResult{err: docker.Logs(p, opts, safe)}
+	})
+}
+
+func (d *Docker) Logs(p *Project, opts LogsOptions, h LogHandler) error {
+	l, err := d.ListContainers(p)
+	if err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, len(l))
+	for _, c := range l {
+		wg.Add(1)
+		go func(c *Container) {
+			defer wg.Done()
+			if err := d.containerLogs(c, opts, h); err != nil && err != ErrLogsClosed {
+				errs <- err
+			}
+		}(c)
+	}
+
+	wg.Wait()
+	close(errs)
+
+	return <-errs
+}
+
+func (d *Docker) containerLogs(c *Container, opts LogsOptions, h LogHandler) error {
+	Debug("Retrieving container logs", "container", c.GetShortID(), "end-point", d.endPoint)
+
+	stdout := &logWriter{handler: h, line: LogLine{
+		DockerEndPoint: d.endPoint, Container: c.GetShortID(), Stream: "stdout",
+	}}
+
+	stderr := &logWriter{handler: h, line: LogLine{
+		DockerEndPoint: d.endPoint, Container: c.GetShortID(), Stream: "stderr",
+	}}
+
+	tail := opts.Tail
+	if tail == "" {
+		tail = "all"
+	}
+
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	err := d.client.Logs(docker.LogsOptions{
+		Context:      ctx,
+		Container:    c.ID,
+		OutputStream: stdout,
+		ErrorStream:  stderr,
+		Follow:       opts.Follow,
+		Tail:         tail,
+		Since:        opts.Since,
+		Stdout:       opts.Stdout || !opts.Stderr,
+		Stderr:       opts.Stderr || !opts.Stdout,
+	})
+
+	if stdout.err != nil || stderr.err != nil {
+		return ErrLogsClosed
+	}
+
+	if ctx.Err() != nil {
+		return ErrLogsClosed
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if err := stdout.Flush(); err != nil {
+		return ErrLogsClosed
+	}
+
+	if err := stderr.Flush(); err != nil {
+		return ErrLogsClosed
+	}
+
+	return nil
+}
+
+type logWriter struct {
+	handler LogHandler
+	line    LogLine
+	buf     []byte
+	err     error
+}
+
+func (w *logWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+
+		if err := w.emit(string(w.buf[:i])); err != nil {
+			return 0, err
+		}
+
+		w.buf = w.buf[i+1:]
+	}
+
+	return len(p), nil
+}
+
+func (w *logWriter) Flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+
+	line := string(w.buf)
+	w.buf = nil
+	return w.emit(line)
+}
+
+func (w *logWriter) emit(text string) error {
+	l := w.line
+	l.Line = text
+	if err := w.handler(&l); err != nil {
+		w.err = err
+		return err
+	}
+
+	return nil
+}
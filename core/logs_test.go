@@ -0,0 +1,44 @@
+package core
+
+import (
+	"errors"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *CoreSuite) TestLogWriter_Write(c *C) {
+	var lines []*LogLine
+	w := &logWriter{
+		line: LogLine{DockerEndPoint: "tcp://foo", Container: "bar", Stream: "stdout"},
+		handler: func(l *LogLine) error {
+			lines = append(lines, l)
+			return nil
+		},
+	}
+
+	w.Write([]byte("foo\nba"))
+	w.Write([]byte("r\nqux"))
+	c.Assert(lines, HasLen, 2)
+	c.Assert(lines[0].Line, Equals, "foo")
+	c.Assert(lines[1].Line, Equals, "bar")
+	c.Assert(lines[1].DockerEndPoint, Equals, "tcp://foo")
+	c.Assert(lines[1].Container, Equals, "bar")
+	c.Assert(lines[1].Stream, Equals, "stdout")
+
+	c.Assert(w.Flush(), IsNil)
+	c.Assert(lines, HasLen, 3)
+	c.Assert(lines[2].Line, Equals, "qux")
+}
+
+func (s *CoreSuite) TestLogWriter_WriteHandlerError(c *C) {
+	fail := errors.New("foo")
+	w := &logWriter{handler: func(l *LogLine) error {
+		return fail
+	}}
+
+	_, err := w.Write([]byte("foo\n"))
+	c.Assert(err, Equals, fail)
+
+	_, err = w.Write([]byte("bar\n"))
+	c.Assert(err, Equals, fail)
+}
@@ -0,0 +1,61 @@
+package core
+
+import (
+	"io"
+	"sync"
+)
+
+// EndPointWriter is implemented by the deploy outputs that need to know the
+// docker end point producing the output
+type EndPointWriter interface {
+	io.Writer
+	ForEndPoint(endPoint string) io.Writer
+}
+
+// MultiEndPointWriter is like io.MultiWriter but the writers implementing
+// EndPointWriter are given the end point. The writes are serialized, so the
+// writers returned by ForEndPoint can be used by concurrent deploys.
+func MultiEndPointWriter(writers ...io.Writer) EndPointWriter {
+	return &multiEndPointWriter{writers: writers}
+}
+
+type multiEndPointWriter struct {
+	sync.Mutex
+	writers []io.Writer
+}
+
+func (m *multiEndPointWriter) Write(p []byte) (int, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	return io.MultiWriter(m.writers...).Write(p)
+}
+
+func (m *multiEndPointWriter) ForEndPoint(endPoint string) io.Writer {
+	writers := make([]io.Writer, len(m.writers))
+	for i, w := range m.writers {
+		writers[i] = forEndPoint(w, endPoint)
+	}
+
+	return &lockedWriter{m: &m.Mutex, w: io.MultiWriter(writers...)}
+}
+
+type lockedWriter struct {
+	m *sync.Mutex
+	w io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	return l.w.Write(p)
+}
+
+func forEndPoint(output io.Writer, endPoint string) io.Writer {
+	if w, ok := output.(EndPointWriter); ok {
+		return w.ForEndPoint(endPoint)
+	}
+
+	return output
+}
@@ -0,0 +1,56 @@
+package core
+
+import (
+	"bytes"
+	"io"
+	"sync"
+
+	. "gopkg.in/check.v1"
+)
+
+type bufferEndPointWriter struct {
+	bytes.Buffer
+	endPoints []string
+}
+
+func (w *bufferEndPointWriter) ForEndPoint(endPoint string) io.Writer {
+	w.endPoints = append(w.endPoints, endPoint)
+	return &w.Buffer
+}
+
+func (s *CoreSuite) TestMultiEndPointWriter(c *C) {
+	plain := bytes.NewBuffer(nil)
+	tagged := &bufferEndPointWriter{}
+
+	w := MultiEndPointWriter(plain, tagged)
+	forEndPoint(w, "tcp://foo").Write([]byte("foo"))
+	w.Write([]byte("bar"))
+
+	c.Assert(plain.String(), Equals, "foobar")
+	c.Assert(tagged.String(), Equals, "foobar")
+	c.Assert(tagged.endPoints, DeepEquals, []string{"tcp://foo"})
+}
+
+func (s *CoreSuite) TestForEndPoint(c *C) {
+	plain := bytes.NewBuffer(nil)
+	c.Assert(forEndPoint(plain, "tcp://foo"), Equals, plain)
+}
+
+func (s *CoreSuite) TestMultiEndPointWriterConcurrent(c *C) {
+	output := bytes.NewBuffer(nil)
+	w := MultiEndPointWriter(output)
+
+	var wg sync.WaitGroup
+	for _, endPoint := range []string{"tcp://foo", "tcp://bar", "tcp://qux"} {
+		wg.Add(1)
+		go func(ew io.Writer) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				ew.Write([]byte("line\n"))
+			}
+		}(forEndPoint(w, endPoint))
+	}
+
+	wg.Wait()
+	c.Assert(output.Len(), Equals, 3*1000*len("line\n"))
+}
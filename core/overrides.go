@@ -0,0 +1,172 @@
+package core
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// notOverridable are the project fields that can't be overridden by
+// environment, they are shared by every environment
+var notOverridable = map[string]bool{
+	"Name":              true,
+	"UseShortRevisions": true,
+	"Links":             true,
+	"LinkNames":         true,
+	"LinkedBy":          true,
+	"Environments":      true,
+	"EnvironmentNames":  true,
+	"TaskStatus":        true,
+	"Overrides":         true,
+	"Extends":           true,
+	"Replace":           true,
+}
+
+// notInherited are the project fields that are not inherited from a template
+var notInherited = map[string]bool{
+	"Name":              true,
+	"UseShortRevisions": true,
+	"Links":             true,
+	"LinkedBy":          true,
+	"Environments":      true,
+	"TaskStatus":        true,
+	"Overrides":         true,
+	"Extends":           true,
+	"Replace":           true,
+}
+
+// ForEnvironment returns the effective project at the environment, a copy of
+// the project with the non-zero settings of its override for the environment,
+// or the project itself if it has no override
+func (p *Project) ForEnvironment(environment string) *Project {
+	o, ok := p.Overrides[environment]
+	if !ok || o == nil {
+		return p
+	}
+
+	r := *p
+	dst, src := reflect.ValueOf(&r).Elem(), reflect.ValueOf(o).Elem()
+	for i := 0; i < dst.NumField(); i++ {
+		name := dst.Type().Field(i).Name
+		if notOverridable[name] || isZero(src.Field(i)) {
+			continue
+		}
+
+		dst.Field(i).Set(src.Field(i))
+	}
+
+	return &r
+}
+
+// Inherit sets the settings of the template not set at the project, the values
+// of the multi-valued keys are appended to the ones of the template unless the
+// key is listed at Replace
+func (p *Project) Inherit(t *Project) {
+	dst, src := reflect.ValueOf(p).Elem(), reflect.ValueOf(t).Elem()
+	for i := 0; i < dst.NumField(); i++ {
+		f := dst.Type().Field(i)
+		if notInherited[f.Name] || isZero(src.Field(i)) || p.replaces(f) {
+			continue
+		}
+
+		switch {
+		case f.Type.Kind() == reflect.Slice:
+			dst.Field(i).Set(appendUnique(src.Field(i), dst.Field(i)))
+		case isZero(dst.Field(i)):
+			dst.Field(i).Set(src.Field(i))
+		}
+	}
+}
+
+func (p *Project) replaces(f reflect.StructField) bool {
+	for _, key := range p.Replace {
+		if strings.EqualFold(key, fieldKey(f)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// appendUnique returns a new slice with the values of a followed by the ones
+// of b not found at a
+func appendUnique(a, b reflect.Value) reflect.Value {
+	r := reflect.AppendSlice(reflect.MakeSlice(a.Type(), 0, a.Len()+b.Len()), a)
+	for i := 0; i < b.Len(); i++ {
+		found := false
+		for j := 0; j < a.Len(); j++ {
+			if reflect.DeepEqual(a.Index(j).Interface(), b.Index(i).Interface()) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			r = reflect.Append(r, b.Index(i))
+		}
+	}
+
+	return r
+}
+
+// CheckOverride returns an error for every setting of the override that can't
+// be overridden by environment
+func CheckOverride(o *Project) []error {
+	var errs []error
+	v := reflect.ValueOf(o).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Type().Field(i)
+		if notOverridable[f.Name] && f.Name != "Name" && !isZero(v.Field(i)) {
+			errs = append(errs, fmt.Errorf("%s can't be overridden by environment", fieldKey(f)))
+		}
+	}
+
+	return errs
+}
+
+func fieldKey(f reflect.StructField) string {
+	if tag := f.Tag.Get("gcfg"); tag != "" {
+		return tag
+	}
+
+	return f.Name
+}
+
+func isZero(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		return v.IsNil()
+	}
+
+	return v.Interface() == reflect.Zero(v.Type()).Interface()
+}
+
+var memoryUnits = map[string]int64{
+	"":  1,
+	"k": 1 << 10,
+	"m": 1 << 20,
+	"g": 1 << 30,
+}
+
+// ParseMemory returns the bytes of a memory limit given as a number with an
+// optional unit: b, k, m or g, like 512m or 1gb
+func ParseMemory(memory string) (int64, error) {
+	if memory == "" {
+		return 0, nil
+	}
+
+	s := strings.ToLower(strings.TrimSpace(memory))
+	i := strings.IndexFunc(s, func(r rune) bool { return r < '0' || r > '9' })
+	if i == -1 {
+		i = len(s)
+	}
+
+	unit, ok := memoryUnits[strings.TrimSuffix(s[i:], "b")]
+	n, err := strconv.ParseInt(s[:i], 10, 64)
+	if err != nil || !ok {
+		return 0, fmt.Errorf("Malformed memory limit %q", memory)
+	}
+
+	return n * unit, nil
+}
@@ -0,0 +1,88 @@
+package core
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+func (s *CoreSuite) TestProject_ForEnvironment(c *C) {
+	p := &Project{
+		Name:    "foo",
+		History: 3,
+		Restart: "always",
+		Binds:   []string{"/data:/data"},
+		Overrides: map[string]*Project{
+			"testing": {History: 1, Binds: []string{"/tmp:/data"}, Memory: "512m"},
+		},
+	}
+
+	c.Assert(p.ForEnvironment("live"), Equals, p)
+
+	t := p.ForEnvironment("testing")
+	c.Assert(t, Not(Equals), p)
+	c.Assert(t.Name, Equals, "foo")
+	c.Assert(t.History, Equals, 1)
+	c.Assert(t.Restart, Equals, "always")
+	c.Assert(t.Binds, DeepEquals, []string{"/tmp:/data"})
+	c.Assert(t.Memory, Equals, "512m")
+	c.Assert(p.History, Equals, 3)
+	c.Assert(p.Binds, DeepEquals, []string{"/data:/data"})
+}
+
+func (s *CoreSuite) TestCheckOverride(c *C) {
+	c.Assert(CheckOverride(&Project{Name: "foo", History: 1}), HasLen, 0)
+
+	errs := CheckOverride(&Project{EnvironmentNames: []string{"live"}, UseShortRevisions: true})
+	c.Assert(errs, HasLen, 2)
+	c.Assert(errs[0], ErrorMatches, "UseShortRevisions can't be overridden by environment")
+	c.Assert(errs[1], ErrorMatches, "Environment can't be overridden by environment")
+}
+
+func (s *CoreSuite) TestParseMemory(c *C) {
+	for memory, bytes := range map[string]int64{
+		"":     0,
+		"1024": 1024,
+		"512k": 512 << 10,
+		"512m": 512 << 20,
+		"1gb":  1 << 30,
+		" 2G ": 2 << 30,
+		"100b": 100,
+	} {
+		n, err := ParseMemory(memory)
+		c.Assert(err, IsNil)
+		c.Assert(n, Equals, bytes)
+	}
+
+	for _, memory := range []string{"m", "1t", "1.5g", "-1m"} {
+		_, err := ParseMemory(memory)
+		c.Assert(err, ErrorMatches, "Malformed memory limit .*")
+	}
+}
+
+func (s *CoreSuite) TestProject_Inherit(c *C) {
+	t := &Project{
+		Name:             "template",
+		Restart:          "always",
+		History:          5,
+		Files:            []string{"a", "b"},
+		Binds:            []string{"/data:/data"},
+		EnvironmentNames: []string{"live"},
+	}
+
+	p := &Project{
+		Name:             "foo",
+		History:          1,
+		Files:            []string{"b", "c"},
+		Binds:            []string{"/tmp:/data"},
+		EnvironmentNames: []string{"testing"},
+		Replace:          []string{"volume", "Environment"},
+	}
+
+	p.Inherit(t)
+	c.Assert(p.Name, Equals, "foo")
+	c.Assert(p.Restart, Equals, "always")
+	c.Assert(p.History, Equals, 1)
+	c.Assert(p.Files, DeepEquals, []string{"a", "b", "c"})
+	c.Assert(p.Binds, DeepEquals, []string{"/tmp:/data"})
+	c.Assert(p.EnvironmentNames, DeepEquals, []string{"testing"})
+	c.Assert(t.Files, DeepEquals, []string{"a", "b"})
+}
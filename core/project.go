@@ -12,7 +12,8 @@ import (
 )
 
 const (
-	Deploy Task = "deploy"
+	Deploy    Task = "deploy"
+	Reconcile Task = "reconcile"
 )
 
 type Project struct {
@@ -25,23 +26,49 @@ type Project struct {
 	UseShortRevisions   bool     `default:"true"`
 	Files               []string `gcfg:"File"`
 	TestCommand         string
+	PreDeployCommand    string
+	PostDeployCommand   string
 	NoCache             bool
 	Restart             string
 	Ports               []string         `gcfg:"Port"`
 	Binds               []string         `gcfg:"Volume"`
 	VolumesFrom         []string         `gcfg:"VolumeFrom"`
+	Env                 []string         `gcfg:"Env" json:"-"`
 	Links               map[string]*Link `json:"-"`
 	LinkNames           []LinkDefinition `gcfg:"Link"`
 	LinkedBy            []*Project       `json:"-"`
 	Environments        map[string]*Environment
 	EnvironmentNames    []string `gcfg:"Environment"`
 	TaskStatus          TaskStatus
-	WebHook             string `gcfg:"WebHook"`
+	WebHook             string `gcfg:"WebHook" json:"-"`
+	Memory              string
+	CPUShares           int64
+	Overrides           map[string]*Project `json:"-"`
+	Extends             string
+	Replace             []string `gcfg:"Replace"`
 }
 
 func (p *Project) Deploy(environment string, output io.Writer, force bool) []error {
-	e := p.mustGetEnvironment(environment)
-	p.TaskStatus.Start(e, Deploy)
+	return p.DeployWithReport(environment, output, force).Errors
+}
+
+// DeployWithReport deploys the last revision of the project at the given
+// environment, the returned report contains the requested ref, the resolved
+// revision and the result at each docker end point
+func (p *Project) DeployWithReport(environment string, output io.Writer, force bool) *DeployReport {
+	p = p.ForEnvironment(environment)
+	r := &DeployReport{Ref: p.Repository.Info().Branch}
+	fail := func(errs ...error) *DeployReport {
+		r.Errors = errs
+		return r
+	}
+
+	e, err := p.getEnvironment(environment)
+	if err != nil {
+		return fail(err)
+	}
+
+	p.TaskStatus.StartAfter(e, Deploy, Reconcile)
 	defer p.TaskStatus.Stop(e, Deploy)
 
 	Info("Retrieving last dockerfile ...", "project", p)
@@ -49,32 +76,54 @@ func (p *Project) Deploy(environment string, output io.Writer, force bool) []err
 	c := NewGithub(p.GithubToken)
 	blob, err := c.GetDockerFile(p)
 	if err != nil {
-		return []error{err}
+		return fail(err)
 	}
 
 	prevStatus, errs := p.StatusByEnvironment(e)
 	if len(errs) != 0 {
-		return errs
+		return fail(errs...)
 	}
-	r, err := c.GetLastRevision(p)
+	rev, err := c.GetLastRevision(p)
 	if err != nil {
-		return []error{err}
+		return fail(err)
 	}
 
+	r.Revision = rev
 	d, err := NewDockerGroup(e)
 	if err != nil {
-		return []error{err}
+		return fail(err)
 	}
 
-	file := NewDockerfile(blob, p, r, e)
+	file := NewDockerfile(blob, p, rev, e)
 	file.Files, err = c.GetFiles(p)
+	if err != nil {
+		return fail(err)
+	}
+
+	report := d.DeployWithReport(p, rev, file, output, force)
+	report.Ref = r.Ref
+	if len(report.Errors) == 0 {
+		States.Set(p, e, p.GetImageName(rev))
+	}
+
+	p.afterDeploy(prevStatus, e, report.Errors)
+	return report
+}
+
+// Clean removes the containers and the old images of the project at the given
+// environment
+func (p *Project) Clean(environment string) []error {
+	e, err := p.getEnvironment(environment)
+	if err != nil {
+		return []error{err}
+	}
+
+	d, err := NewDockerGroup(e)
 	if err != nil {
 		return []error{err}
 	}
 
-	errs = d.Deploy(p, r, file, output, force)
-	p.afterDeploy(prevStatus, e, errs)
-	return errs
+	return d.Clean(p.ForEnvironment(environment))
 }
 
 func (p *Project) afterDeploy(prevStatus *ProjectStatus, e *Environment, errs []error) {
@@ -110,13 +159,63 @@ func getRunningRevFromStatus(status *ProjectStatus) *string {
 	return s
 }
 
-func (p *Project) mustGetEnvironment(name string) *Environment {
+// RunTask runs the command in a temporary container created from the image
+// running at the given docker end point of the environment, if the end point
+// is empty the HookEndPoint is used. The output of the command is written to
+// output and the exit code is returned.
+func (p *Project) RunTask(environment, command, endPoint string, output io.Writer) (int, error) {
+	e, err := p.getEnvironment(environment)
+	if err != nil {
+		return -1, err
+	}
+
+	if endPoint == "" {
+		endPoint = e.GetHookEndPoint()
+	}
+
+	p = p.ForEnvironment(environment)
+	d, err := p.getDocker(e, endPoint)
+	if err != nil {
+		return -1, err
+	}
+
+	running, err := d.getRunningContainer(p)
+	if err != nil {
+		return -1, err
+	}
+
+	Info("Running task", "project", p, "environment", e, "image", running.Image, "command", command, "end-point", endPoint)
+	return d.RunCommand(p, running.Image, command, output)
+}
+
+func (p *Project) getDocker(e *Environment, endPoint string) (*Docker, error) {
+	if !e.HasDockerEndPoint(endPoint) {
+		return nil, fmt.Errorf("Docker end point %q not defined in environment %q", endPoint, e)
+	}
+
+	return NewDocker(endPoint, e)
+}
+
+func (p *Project) GetImageName(rev Revision) ImageID {
+	c := rev.String()
+	if p.UseShortRevisions {
+		c = rev.GetShort()
+	}
+
+	return ImageID(fmt.Sprintf("%s:%s", p.Name, c))
+}
+
+func (p *Project) HasEnvironment(name string) bool {
+	_, ok := p.Environments[name]
+	return ok
+}
+
+func (p *Project) getEnvironment(name string) (*Environment, error) {
 	if e, ok := p.Environments[name]; ok {
-		return e
+		return e, nil
 	}
 
-	Critical("Environment not defined in project", "project", p, "environment", name)
-	return nil
+	return nil, fmt.Errorf("Environment %q not defined in project %q", name, p.Name)
 }
 
 type ProjectDeployResult struct {
@@ -158,6 +257,25 @@ type ProjectStatus struct {
 	LastRevision      Revision
 	RunningContainers []*Container
 	Containers        []*Container
+	Stats             []*ContainerStats
+	TotalStats        *ResourceStats
+	Drift             []*ContainerDrift `json:",omitempty"`
+}
+
+// IsUpToDate returns true if every docker end point of the environment is
+// running a container from the last revision
+func (s *ProjectStatus) IsUpToDate() bool {
+	if s.LastRevision == nil || len(s.RunningContainers) < len(s.Environment.DockerEndPoints) {
+		return false
+	}
+
+	for _, c := range s.RunningContainers {
+		if !c.Image.IsRevision(s.LastRevision) {
+			return false
+		}
+	}
+
+	return true
 }
 
 func (p *Project) Status() ([]*ProjectStatus, []error) {
@@ -175,6 +293,7 @@ func (p *Project) Status() ([]*ProjectStatus, []error) {
 }
 
 func (p *Project) StatusByEnvironment(e *Environment) (*ProjectStatus, []error) {
+	p = p.ForEnvironment(e.Name)
 	s := &ProjectStatus{Environment: e}
 	c := NewGithub(p.GithubToken)
 
@@ -248,3 +367,27 @@ func (p *Project) String() string {
 	i := p.Repository.Info()
 	return fmt.Sprintf("%s/%s!%s", i.Username, i.Name, i.Branch)
 }
+
+// OutdatedProjects returns the projects deployable at the given environment
+// not running the last revision in every docker end point
+func OutdatedProjects(projects []*Project, environment string) ([]*Project, []error) {
+	var e []error
+	var r []*Project
+	for _, p := range projects {
+		if !p.HasEnvironment(environment) {
+			continue
+		}
+
+		s, err := p.StatusByEnvironment(p.Environments[environment])
+		if len(err) != 0 {
+			e = append(e, err...)
+			continue
+		}
+
+		if !s.IsUpToDate() {
+			r = append(r, p)
+		}
+	}
+
+	return r, e
+}
@@ -0,0 +1,278 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	EventDrift        Event = "drift"
+	EventDesiredState Event = "desired-state"
+)
+
+// DesiredState is the last image successfully deployed of a project at an
+// environment
+type DesiredState struct {
+	Project     string
+	Environment string
+	Image       ImageID
+	Time        time.Time
+}
+
+type DesiredStates struct {
+	states map[string]*DesiredState
+	sync.Mutex
+}
+
+func NewDesiredStates() *DesiredStates {
+	return &DesiredStates{states: make(map[string]*DesiredState, 0)}
+}
+
+// States holds the desired state of every project, is updated after each
+// successful deploy, every change triggers an EventDesiredState so it can be
+// persisted
+var States = NewDesiredStates()
+
+func (s *DesiredStates) Set(p *Project, e *Environment, image ImageID) {
+	state := &DesiredState{
+		Project:     p.Name,
+		Environment: e.Name,
+		Image:       image,
+		Time:        time.Now(),
+	}
+
+	s.Lock()
+	s.states[p.Name+"."+e.Name] = state
+	s.Unlock()
+
+	Events.Trigger(EventDesiredState, state)
+}
+
+// Load adds the given states, usually restored from a store, unless a newer
+// state is already known
+func (s *DesiredStates) Load(states []*DesiredState) {
+	s.Lock()
+	defer s.Unlock()
+
+	for _, state := range states {
+		key := state.Project + "." + state.Environment
+		if current, ok := s.states[key]; ok && current.Time.After(state.Time) {
+			continue
+		}
+
+		s.states[key] = state
+	}
+}
+
+func (s *DesiredStates) Get(p *Project, e *Environment) *DesiredState {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.states[p.Name+"."+e.Name]
+}
+
+type Drift struct {
+	Project        string
+	Environment    string
+	DockerEndPoint string
+	Expected       ImageID
+	Running        []ImageID `json:",omitempty"`
+	Reason         string
+	Fixed          bool
+	Error          string `json:",omitempty"`
+	Time           time.Time
+}
+
+func (d *Drift) String() string {
+	return fmt.Sprintf("%s@%s (%s): %s", d.Project, d.Environment, d.DockerEndPoint, d.Reason)
+}
+
+// Reconciler compares periodically the desired state of every project with the
+// containers at each docker end point, recreating the missing containers and
+// starting the stopped ones
+type Reconciler struct {
+	projects map[string]*Project
+	interval time.Duration
+	stop     chan bool
+	done     chan bool
+	drifts   []*Drift
+	sync.Mutex
+}
+
+func NewReconciler(projects map[string]*Project, interval time.Duration) *Reconciler {
+	return &Reconciler{projects: projects, interval: interval}
+}
+
+func (r *Reconciler) Start() {
+	r.stop = make(chan bool)
+	r.done = make(chan bool)
+	go func() {
+		defer close(r.done)
+		for {
+			r.Reconcile()
+
+			select {
+			case <-r.stop:
+				return
+			case <-time.After(r.interval):
+			}
+		}
+	}()
+}
+
+// Stop stops the reconciler, waiting for the running reconciliation to finish
+func (r *Reconciler) Stop() {
+	close(r.stop)
+	<-r.done
+}
+
+// Drifts returns the drifts found in the last reconciliation
+func (r *Reconciler) Drifts() []*Drift {
+	r.Lock()
+	defer r.Unlock()
+
+	return r.drifts
+}
+
+func (r *Reconciler) Reconcile() []*Drift {
+	var names []string
+	for name := range r.projects {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	var drifts []*Drift
+	for _, name := range names {
+		p := r.projects[name]
+		for _, e := range p.Environments {
+			if !p.TaskStatus.TryStart(e, Reconcile, Deploy) {
+				continue
+			}
+
+			l, errs := p.Reconcile(e)
+			p.TaskStatus.Stop(e, Reconcile)
+			for _, err := range errs {
+				Error(err.Error(), "project", p, "environment", e)
+			}
+
+			drifts = append(drifts, l...)
+		}
+	}
+
+	for _, d := range drifts {
+		if d.Fixed {
+			Info("Drift fixed", "project", d.Project, "environment", d.Environment, "end-point", d.DockerEndPoint, "reason", d.Reason)
+		} else {
+			Warning("Drift not fixed", "project", d.Project, "environment", d.Environment, "end-point", d.DockerEndPoint, "reason", d.Reason, "error", d.Error)
+		}
+
+		Events.Trigger(EventDrift, d)
+	}
+
+	r.Lock()
+	r.drifts = drifts
+	r.Unlock()
+
+	return drifts
+}
+
+// Reconcile brings every docker end point of the environment to the desired
+// state of the project, if no desired state was recorded the image of the
+// newest container is used
+func (p *Project) Reconcile(e *Environment) ([]*Drift, []error) {
+	p = p.ForEnvironment(e.Name)
+	d, err := NewDockerGroup(e)
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	state := States.Get(p, e)
+	if state == nil {
+		l, errs := d.ListContainers(p)
+		if len(errs) != 0 {
+			return nil, errs
+		}
+
+		if len(l) == 0 {
+			return nil, nil
+		}
+
+		sort.Sort(ContainersByCreated(l))
+		States.Set(p, e, l[len(l)-1].Image)
+		state = States.Get(p, e)
+	}
+
+	var drifts []*Drift
+	for _, r := range d.batchInterfaceResult(func(docker *Docker) interface{} {
+		return docker.Reconcile(p, state.Image)
+	}) {
+		if drift := r.(*Drift); drift != nil {
+			drift.Environment = e.Name
+			drifts = append(drifts, drift)
+		}
+	}
+
+	return drifts, nil
+}
+
+// Reconcile starts a stopped container or runs a new one from the given
+// image if no container of the project is running it, a nil Drift is
+// returned when the end point is already at the desired state
+func (d *Docker) Reconcile(p *Project, image ImageID) *Drift {
+	drift := &Drift{
+		Project:        p.Name,
+		DockerEndPoint: d.endPoint,
+		Expected:       image,
+		Time:           time.Now(),
+	}
+
+	l, err := d.ListContainers(p)
+	if err != nil {
+		drift.Reason = "Unable to list containers"
+		drift.Error = err.Error()
+		return drift
+	}
+
+	var stopped *Container
+	for _, c := range l {
+		if c.IsRunning() {
+			drift.Running = append(drift.Running, c.Image)
+		}
+
+		if c.Image == image && !c.IsRunning() {
+			stopped = c
+		}
+	}
+
+	for _, running := range drift.Running {
+		if running == image {
+			return nil
+		}
+	}
+
+	if len(drift.Running) != 0 {
+		drift.Reason = "Running an unexpected image"
+		return drift
+	}
+
+	if stopped != nil {
+		drift.Reason = fmt.Sprintf("Container %s stopped", stopped.GetShortID())
+		err = d.startContainer(p, stopped)
+	} else {
+		drift.Reason = "Container missing"
+		if err = d.cleanContainers(p); err == nil {
+			err = d.RunImage(p, image)
+		}
+	}
+
+	if err != nil {
+		drift.Error = err.Error()
+	} else {
+		drift.Fixed = true
+	}
+
+	return drift
+}
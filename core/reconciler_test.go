@@ -0,0 +1,92 @@
+package core
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/fsouza/go-dockerclient/testing"
+	. "gopkg.in/check.v1"
+)
+
+func (s *CoreSuite) TestDesiredStates(c *C) {
+	p := &Project{Name: "foo"}
+	e := &Environment{Name: "bar"}
+
+	st := NewDesiredStates()
+	c.Assert(st.Get(p, e), IsNil)
+
+	st.Set(p, e, ImageID("foo:qux"))
+	c.Assert(st.Get(p, e).Image, Equals, ImageID("foo:qux"))
+	c.Assert(st.Get(p, e).Project, Equals, "foo")
+	c.Assert(st.Get(p, e).Environment, Equals, "bar")
+}
+
+func (s *CoreSuite) TestDesiredStates_Load(c *C) {
+	p := &Project{Name: "foo"}
+	e := &Environment{Name: "bar"}
+
+	st := NewDesiredStates()
+	st.Load([]*DesiredState{{Project: "foo", Environment: "bar", Image: "foo:old"}})
+	c.Assert(st.Get(p, e).Image, Equals, ImageID("foo:old"))
+
+	st.Set(p, e, ImageID("foo:qux"))
+	st.Load([]*DesiredState{{Project: "foo", Environment: "bar", Image: "foo:old"}})
+	c.Assert(st.Get(p, e).Image, Equals, ImageID("foo:qux"))
+}
+
+func (s *CoreSuite) TestDocker_Reconcile(c *C) {
+	m, _ := testing.NewServer("127.0.0.1:0", nil, nil)
+	p := &Project{Name: "foo", Repository: "git@github.com:foo/bar.git"}
+	rev := Revision{"foo": "bar"}
+
+	d, _ := NewDocker(m.URL(), nil)
+	err := d.Deploy(p, rev, &Dockerfile{content: []byte("FROM base\n")}, bytes.NewBuffer(nil), false)
+	c.Assert(err, IsNil)
+	c.Assert(d.Reconcile(p, p.GetImageName(rev)), IsNil)
+
+	c.Assert(d.cleanContainers(p), IsNil)
+	drift := d.Reconcile(p, p.GetImageName(rev))
+	c.Assert(drift, NotNil)
+	c.Assert(drift.Fixed, Equals, true)
+	c.Assert(drift.Reason, Equals, "Container missing")
+
+	l, _ := d.ListContainers(p)
+	c.Assert(l, HasLen, 1)
+	c.Assert(l[0].IsRunning(), Equals, true)
+}
+
+func (s *CoreSuite) TestDocker_ReconcileUnexpectedImage(c *C) {
+	m, _ := testing.NewServer("127.0.0.1:0", nil, nil)
+	p := &Project{Name: "foo", Repository: "git@github.com:foo/bar.git"}
+
+	d, _ := NewDocker(m.URL(), nil)
+	err := d.Deploy(p, Revision{"foo": "bar"}, &Dockerfile{content: []byte("FROM base\n")}, bytes.NewBuffer(nil), false)
+	c.Assert(err, IsNil)
+
+	drift := d.Reconcile(p, p.GetImageName(Revision{"foo": "qux"}))
+	c.Assert(drift, NotNil)
+	c.Assert(drift.Fixed, Equals, false)
+	c.Assert(drift.Running, HasLen, 1)
+}
+
+func (s *CoreSuite) TestReconciler_Stop(c *C) {
+	r := NewReconciler(map[string]*Project{}, time.Hour)
+	r.Start()
+	r.Stop()
+
+	select {
+	case <-r.done:
+	default:
+		c.Fatal("reconciler still running after Stop")
+	}
+}
+
+func (s *CoreSuite) TestReconciler_ReconcileSkipsDeploys(c *C) {
+	e := &Environment{Name: "bar"}
+	p := &Project{Name: "foo", Environments: map[string]*Environment{"bar": e}, TaskStatus: TaskStatus{}}
+	p.TaskStatus.Start(e, Deploy)
+
+	r := NewReconciler(map[string]*Project{"foo": p}, time.Hour)
+	c.Assert(r.Reconcile(), HasLen, 0)
+	c.Assert(p.TaskStatus.IsRunning(e, Reconcile), Equals, false)
+}
@@ -0,0 +1,143 @@
+package core
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrNoPreviousRevision = errors.New("No previous revision available at every docker end point")
+	ErrRevisionNotFound   = errors.New("Revision not available at every docker end point")
+)
+
+// Rollback replaces the containers of the project at the environment with a
+// container from the image of the given revision, the image must be available
+// at every docker end point, as the History images are. With an empty revision
+// the one previous to the running revision is used.
+func (p *Project) Rollback(environment, revision string) (ImageID, []error) {
+	e, err := p.getEnvironment(environment)
+	if err != nil {
+		return "", []error{err}
+	}
+
+	p = p.ForEnvironment(environment)
+	p.TaskStatus.StartAfter(e, Deploy, Reconcile)
+	defer p.TaskStatus.Stop(e, Deploy)
+
+	d, err := NewDockerGroup(e)
+	if err != nil {
+		return "", []error{err}
+	}
+
+	images, errs := d.ListImages(p)
+	if len(errs) != 0 {
+		return "", errs
+	}
+
+	containers, errs := d.ListContainers(p)
+	if len(errs) != 0 {
+		return "", errs
+	}
+
+	var running []ImageID
+	for _, c := range containers {
+		if c.IsRunning() {
+			running = append(running, c.Image)
+		}
+	}
+
+	image, err := findRollbackImage(p, len(d.dockers), images, running, revision)
+	if err != nil {
+		return "", []error{err}
+	}
+
+	Info("Rolling back", "project", p, "environment", e, "revision", image.GetRevisionString())
+	errs = d.batchErrorResult(func(docker *Docker) interface{} {
+		err := docker.cleanContainers(p)
+		if err == nil {
+			err = docker.RunImage(p, image)
+		}
+
+		return &errorResult{err: err}
+	})
+
+	if len(errs) == 0 {
+		States.Set(p, e, image)
+	}
+
+	return image, errs
+}
+
+// findRollbackImage returns the newest image, available at every end point,
+// matching the revision or, if revision is empty, older than the running ones
+func findRollbackImage(p *Project, endPoints int, images []*Image, running []ImageID, revision string) (ImageID, error) {
+	count := make(map[ImageID]int, 0)
+	created := make(map[ImageID]int64, 0)
+	for _, i := range images {
+		for _, tag := range i.RepoTags {
+			id := ImageID(tag)
+			if !strings.Contains(tag, ":") || id.GetProjectString() != p.Name || id.GetRevisionString() == LatestTag {
+				continue
+			}
+
+			count[id]++
+			if i.Created > created[id] {
+				created[id] = i.Created
+			}
+		}
+	}
+
+	var candidates []ImageID
+	for id, n := range count {
+		if n >= endPoints {
+			candidates = append(candidates, id)
+		}
+	}
+
+	sort.Sort(sort.Reverse(imageIDsByCreated{candidates, created}))
+	if revision != "" {
+		for _, id := range candidates {
+			if strings.HasPrefix(id.GetRevisionString(), revision) {
+				return id, nil
+			}
+		}
+
+		return "", ErrRevisionNotFound
+	}
+
+	isRunning := func(id ImageID) bool {
+		for _, r := range running {
+			if r == id {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	passed := false
+	for _, id := range candidates {
+		if isRunning(id) {
+			passed = true
+			continue
+		}
+
+		if passed {
+			return id, nil
+		}
+	}
+
+	return "", ErrNoPreviousRevision
+}
+
+type imageIDsByCreated struct {
+	ids     []ImageID
+	created map[ImageID]int64
+}
+
+func (c imageIDsByCreated) Len() int      { return len(c.ids) }
+func (c imageIDsByCreated) Swap(i, j int) { c.ids[i], c.ids[j] = c.ids[j], c.ids[i] }
+func (c imageIDsByCreated) Less(i, j int) bool {
+	return c.created[c.ids[i]] < c.created[c.ids[j]]
+}
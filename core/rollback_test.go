@@ -0,0 +1,36 @@
+package core
+
+import (
+	"github.com/fsouza/go-dockerclient"
+	. "gopkg.in/check.v1"
+)
+
+func (s *CoreSuite) TestFindRollbackImage(c *C) {
+	p := &Project{Name: "foo"}
+	images := []*Image{
+		{"a", docker.APIImages{RepoTags: []string{"foo:1", "foo:latest"}, Created: 3}},
+		{"a", docker.APIImages{RepoTags: []string{"foo:2"}, Created: 2}},
+		{"a", docker.APIImages{RepoTags: []string{"foo:3"}, Created: 1}},
+		{"a", docker.APIImages{RepoTags: []string{"foobar:4"}, Created: 0}},
+		{"b", docker.APIImages{RepoTags: []string{"foo:1", "foo:latest"}, Created: 3}},
+		{"b", docker.APIImages{RepoTags: []string{"foo:3"}, Created: 1}},
+	}
+
+	image, err := findRollbackImage(p, 2, images, []ImageID{"foo:1"}, "")
+	c.Assert(err, IsNil)
+	c.Assert(image, Equals, ImageID("foo:3"))
+
+	image, err = findRollbackImage(p, 1, images, []ImageID{"foo:1"}, "")
+	c.Assert(err, IsNil)
+	c.Assert(image, Equals, ImageID("foo:2"))
+
+	image, err = findRollbackImage(p, 2, images, []ImageID{"foo:1"}, "1")
+	c.Assert(err, IsNil)
+	c.Assert(image, Equals, ImageID("foo:1"))
+
+	_, err = findRollbackImage(p, 2, images, []ImageID{"foo:1"}, "2")
+	c.Assert(err, Equals, ErrRevisionNotFound)
+
+	_, err = findRollbackImage(p, 2, images, []ImageID{"foo:3"}, "")
+	c.Assert(err, Equals, ErrNoPreviousRevision)
+}
@@ -0,0 +1,191 @@
+package core
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/fsouza/go-dockerclient"
+)
+
+// StatsTimeout is the maximum time waited for the stats of a container, the
+// containers exceeding it are reported without stats.
+var StatsTimeout = 2 * time.Second
+
+type ResourceStats struct {
+	CPUPercentage float64
+	MemoryUsage   uint64
+	MemoryLimit   uint64
+	NetworkRx     uint64
+	NetworkTx     uint64
+	BlockRead     uint64
+	BlockWrite    uint64
+}
+
+func (s *ResourceStats) Add(o *ResourceStats) {
+	s.CPUPercentage += o.CPUPercentage
+	s.MemoryUsage += o.MemoryUsage
+	s.MemoryLimit += o.MemoryLimit
+	s.NetworkRx += o.NetworkRx
+	s.NetworkTx += o.NetworkTx
+	s.BlockRead += o.BlockRead
+	s.BlockWrite += o.BlockWrite
+}
+
+type ContainerStats struct {
+	DockerEndPoint string
+	Container      string
+	Read           time.Time
+	ResourceStats
+}
+
+func NewContainerStats(endPoint string, c *Container, raw *docker.Stats) *ContainerStats {
+	s := &ContainerStats{
+		DockerEndPoint: endPoint,
+		Container:      c.GetShortID(),
+		Read:           raw.Read,
+	}
+
+	s.CPUPercentage = calculateCPUPercentage(raw)
+	s.MemoryUsage = raw.MemoryStats.Usage
+	s.MemoryLimit = raw.MemoryStats.Limit
+
+	if len(raw.Networks) == 0 {
+		s.NetworkRx = raw.Network.RxBytes
+		s.NetworkTx = raw.Network.TxBytes
+	}
+
+	for _, n := range raw.Networks {
+		s.NetworkRx += n.RxBytes
+		s.NetworkTx += n.TxBytes
+	}
+
+	for _, e := range raw.BlkioStats.IOServiceBytesRecursive {
+		switch e.Op {
+		case "Read":
+			s.BlockRead += e.Value
+		case "Write":
+			s.BlockWrite += e.Value
+		}
+	}
+
+	return s
+}
+
+func calculateCPUPercentage(raw *docker.Stats) float64 {
+	cpu := float64(raw.CPUStats.CPUUsage.TotalUsage) - float64(raw.PreCPUStats.CPUUsage.TotalUsage)
+	system := float64(raw.CPUStats.SystemCPUUsage) - float64(raw.PreCPUStats.SystemCPUUsage)
+	if cpu <= 0 || system <= 0 {
+		return 0
+	}
+
+	cpus := len(raw.CPUStats.CPUUsage.PercpuUsage)
+	if cpus == 0 {
+		cpus = 1
+	}
+
+	return cpu / system * float64(cpus) * 100
+}
+
+// SumStats returns the aggregate of the given container stats
+func SumStats(l []*ContainerStats) *ResourceStats {
+	r := &ResourceStats{}
+	for _, s := range l {
+		r.Add(&s.ResourceStats)
+	}
+
+	return r
+}
+
+// StatsByEnvironment returns the stats of the running containers of the
+// project at every docker end point of the environment
+func (p *Project) StatsByEnvironment(e *Environment) ([]*ContainerStats, []error) {
+	d, err := NewDockerGroup(e)
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	l, errs := d.ListContainers(p)
+	if len(errs) != 0 {
+		return nil, errs
+	}
+
+	return d.Stats(l)
+}
+
+// RunningStats returns the stats of the running containers of the status,
+// they are not retrieved by StatusByEnvironment since every container can take
+// up to StatsTimeout
+func (s *ProjectStatus) RunningStats() ([]*ContainerStats, []error) {
+	d, err := NewDockerGroup(s.Environment)
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	return d.Stats(s.RunningContainers)
+}
+
+// Stats returns the stats of the running containers, the containers are
+// queried concurrently and every one is limited by StatsTimeout
+func (d *DockerGroup) Stats(containers []*Container) ([]*ContainerStats, []error) {
+	var m sync.Mutex
+	var wg sync.WaitGroup
+	var r []*ContainerStats
+	var e []error
+
+	for _, c := range containers {
+		docker, ok := d.dockers[c.DockerEndPoint]
+		if !ok || !c.IsRunning() {
+			continue
+		}
+
+		wg.Add(1)
+		go func(docker *Docker, c *Container) {
+			defer wg.Done()
+			s, err := docker.Stats(c)
+
+			m.Lock()
+			defer m.Unlock()
+			if err != nil {
+				e = append(e, err)
+			} else {
+				r = append(r, s)
+			}
+		}(docker, c)
+	}
+
+	wg.Wait()
+	return r, e
+}
+
+func (d *Docker) Stats(c *Container) (*ContainerStats, error) {
+	stats := make(chan *docker.Stats, 1)
+	done := make(chan bool)
+	errs := make(chan error, 1)
+
+	go func() {
+		errs <- d.client.Stats(docker.StatsOptions{
+			ID:      c.ID,
+			Stats:   stats,
+			Stream:  false,
+			Done:    done,
+			Timeout: StatsTimeout,
+		})
+	}()
+
+	select {
+	case s, ok := <-stats:
+		if !ok {
+			if err := <-errs; err != nil {
+				return nil, err
+			}
+
+			return nil, fmt.Errorf("No stats returned for container %q at %q", c.GetShortID(), d.endPoint)
+		}
+
+		return NewContainerStats(d.endPoint, c, s), nil
+	case <-time.After(StatsTimeout):
+		close(done)
+		return nil, fmt.Errorf("Timeout retrieving stats for container %q at %q", c.GetShortID(), d.endPoint)
+	}
+}
@@ -0,0 +1,60 @@
+package core
+
+import (
+	"github.com/fsouza/go-dockerclient"
+	. "gopkg.in/check.v1"
+)
+
+func (s *CoreSuite) TestNewContainerStats(c *C) {
+	raw := &docker.Stats{}
+	raw.CPUStats.CPUUsage.TotalUsage = 300
+	raw.CPUStats.CPUUsage.PercpuUsage = []uint64{150, 150}
+	raw.CPUStats.SystemCPUUsage = 2000
+	raw.PreCPUStats.CPUUsage.TotalUsage = 100
+	raw.PreCPUStats.SystemCPUUsage = 1000
+	raw.MemoryStats.Usage = 42
+	raw.MemoryStats.Limit = 84
+	raw.Networks = map[string]docker.NetworkStats{
+		"eth0": docker.NetworkStats{RxBytes: 1, TxBytes: 2},
+		"eth1": docker.NetworkStats{RxBytes: 3, TxBytes: 4},
+	}
+	raw.BlkioStats.IOServiceBytesRecursive = []docker.BlkioStatsEntry{
+		docker.BlkioStatsEntry{Op: "Read", Value: 10},
+		docker.BlkioStatsEntry{Op: "Write", Value: 20},
+		docker.BlkioStatsEntry{Op: "Total", Value: 30},
+	}
+
+	co := &Container{APIContainers: docker.APIContainers{ID: "123456789123456789"}}
+	st := NewContainerStats("tcp://foo", co, raw)
+
+	c.Assert(st.DockerEndPoint, Equals, "tcp://foo")
+	c.Assert(st.Container, Equals, "123456789123")
+	c.Assert(st.CPUPercentage, Equals, 40.0)
+	c.Assert(st.MemoryUsage, Equals, uint64(42))
+	c.Assert(st.MemoryLimit, Equals, uint64(84))
+	c.Assert(st.NetworkRx, Equals, uint64(4))
+	c.Assert(st.NetworkTx, Equals, uint64(6))
+	c.Assert(st.BlockRead, Equals, uint64(10))
+	c.Assert(st.BlockWrite, Equals, uint64(20))
+}
+
+func (s *CoreSuite) TestNewContainerStatsWithoutPreviousCPU(c *C) {
+	raw := &docker.Stats{}
+	raw.CPUStats.CPUUsage.TotalUsage = 300
+	raw.CPUStats.SystemCPUUsage = 2000
+	raw.PreCPUStats.CPUUsage.TotalUsage = 300
+
+	st := NewContainerStats("tcp://foo", &Container{}, raw)
+	c.Assert(st.CPUPercentage, Equals, 0.0)
+}
+
+func (s *CoreSuite) TestSumStats(c *C) {
+	l := []*ContainerStats{
+		&ContainerStats{ResourceStats: ResourceStats{CPUPercentage: 10, MemoryUsage: 1}},
+		&ContainerStats{ResourceStats: ResourceStats{CPUPercentage: 5, MemoryUsage: 2}},
+	}
+
+	r := SumStats(l)
+	c.Assert(r.CPUPercentage, Equals, 15.0)
+	c.Assert(r.MemoryUsage, Equals, uint64(3))
+}
@@ -2,10 +2,12 @@ package core
 
 import (
 	"crypto/md5"
+	"encoding/json"
 	"fmt"
 	"regexp"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/fsouza/go-dockerclient"
@@ -85,6 +87,10 @@ func (i ImageID) BelongsTo(p *Project) bool {
 
 func (i ImageID) IsRevision(rev Revision) bool {
 	s := strings.Split(string(i), ":")
+	if len(s) < 2 {
+		return false
+	}
+
 	return strings.HasPrefix(s[1], rev.GetShort())
 }
 
@@ -195,6 +201,10 @@ func (l LinkDefinition) GetProjectName() string {
 
 func (l LinkDefinition) GetAlias() string {
 	tmp := strings.SplitN(string(l), ":", 2)
+	if len(tmp) != 2 || tmp[1] == "" {
+		return tmp[0]
+	}
+
 	return tmp[1]
 }
 
@@ -210,16 +220,85 @@ type Environment struct {
 	EtcdServers     []string `gcfg:"EtcdServer"`
 	Name            string
 	Host            string `gcfg:"Host"`
+	Network         string `gcfg:"Network"`
+	HookEndPoint    string `gcfg:"HookEndPoint"`
 }
 
 func (e *Environment) String() string {
 	return e.Name
 }
 
+func (e *Environment) HasDockerEndPoint(endPoint string) bool {
+	for _, ep := range e.DockerEndPoints {
+		if ep == endPoint {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetHookEndPoint returns the docker end point where the one-off commands,
+// like the deploy hooks, are executed
+func (e *Environment) GetHookEndPoint() string {
+	if e.HookEndPoint != "" {
+		return e.HookEndPoint
+	}
+
+	if len(e.DockerEndPoints) == 0 {
+		return ""
+	}
+
+	return e.DockerEndPoints[0]
+}
+
 type Task string
+
+// TaskStatus holds the tasks running by environment, it is shared by the
+// copies of the project and read by the reconciler, so every access is done
+// holding taskStatusLock
 type TaskStatus map[string]map[Task]time.Time
 
+var (
+	taskStatusLock    sync.RWMutex
+	taskStatusStopped = sync.NewCond(&taskStatusLock)
+)
+
 func (ts TaskStatus) Start(e *Environment, t Task) {
+	taskStatusLock.Lock()
+	defer taskStatusLock.Unlock()
+
+	ts.start(e, t)
+}
+
+// StartAfter starts the task once none of the given tasks is running at the
+// environment, waiting for them to stop
+func (ts TaskStatus) StartAfter(e *Environment, t Task, others ...Task) {
+	taskStatusLock.Lock()
+	defer taskStatusLock.Unlock()
+
+	for ts.isRunningAny(e, others) {
+		taskStatusStopped.Wait()
+	}
+
+	ts.start(e, t)
+}
+
+// TryStart starts the task unless any of the given tasks is running at the
+// environment, false is returned if the task wasn't started
+func (ts TaskStatus) TryStart(e *Environment, t Task, others ...Task) bool {
+	taskStatusLock.Lock()
+	defer taskStatusLock.Unlock()
+
+	if ts.isRunningAny(e, others) {
+		return false
+	}
+
+	ts.start(e, t)
+	return true
+}
+
+func (ts TaskStatus) start(e *Environment, t Task) {
 	if _, ok := ts[e.Name]; !ok {
 		ts[e.Name] = make(map[Task]time.Time)
 	}
@@ -227,7 +306,28 @@ func (ts TaskStatus) Start(e *Environment, t Task) {
 	ts[e.Name][t] = time.Now()
 }
 
+func (ts TaskStatus) isRunningAny(e *Environment, tasks []Task) bool {
+	for _, t := range tasks {
+		if _, ok := ts[e.Name][t]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (ts TaskStatus) IsRunning(e *Environment, t Task) bool {
+	taskStatusLock.RLock()
+	defer taskStatusLock.RUnlock()
+
+	_, ok := ts[e.Name][t]
+	return ok
+}
+
 func (ts TaskStatus) Stop(e *Environment, t Task) {
+	taskStatusLock.Lock()
+	defer taskStatusLock.Unlock()
+
 	if _, ok := ts[e.Name]; !ok {
 		return
 	}
@@ -237,4 +337,13 @@ func (ts TaskStatus) Stop(e *Environment, t Task) {
 	if len(ts[e.Name]) == 0 {
 		delete(ts, e.Name)
 	}
+
+	taskStatusStopped.Broadcast()
+}
+
+func (ts TaskStatus) MarshalJSON() ([]byte, error) {
+	taskStatusLock.RLock()
+	defer taskStatusLock.RUnlock()
+
+	return json.Marshal(map[string]map[Task]time.Time(ts))
 }
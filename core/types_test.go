@@ -1,6 +1,7 @@
 package core
 
 import (
+	"encoding/json"
 	"fmt"
 	"sort"
 	"sync"
@@ -209,6 +210,12 @@ func (s *CoreSuite) TestLinkDefinition_GetAlias(c *C) {
 	c.Assert(l.GetAlias(), Equals, "qux")
 }
 
+func (s *CoreSuite) TestLinkDefinition_GetAliasWithoutAlias(c *C) {
+	l := LinkDefinition("foo")
+
+	c.Assert(l.GetAlias(), Equals, "foo")
+}
+
 func (s *CoreSuite) TestContainersByCreated_Sort(c *C) {
 	list := []*Container{
 		&Container{APIContainers: docker.APIContainers{Created: 3}},
@@ -250,3 +257,86 @@ func (s *CoreSuite) TestTaskStatus_Stop(c *C) {
 	ts.Stop(e, t)
 	c.Assert(ts, HasLen, 0)
 }
+
+func (s *CoreSuite) TestEnvironment_GetHookEndPoint(c *C) {
+	e := &Environment{DockerEndPoints: []string{"tcp://foo", "tcp://bar"}}
+	c.Assert(e.GetHookEndPoint(), Equals, "tcp://foo")
+
+	e.HookEndPoint = "tcp://bar"
+	c.Assert(e.GetHookEndPoint(), Equals, "tcp://bar")
+}
+
+func (s *CoreSuite) TestTaskStatus_IsRunning(c *C) {
+	t := Task(1)
+	e := &Environment{Name: "foo"}
+
+	ts := TaskStatus{}
+	c.Assert(ts.IsRunning(e, t), Equals, false)
+
+	ts.Start(e, t)
+	c.Assert(ts.IsRunning(e, t), Equals, true)
+}
+
+func (s *CoreSuite) TestTaskStatus_Concurrent(c *C) {
+	e := &Environment{Name: "foo"}
+	ts := TaskStatus{}
+
+	done := make(chan bool)
+	go func() {
+		for i := 0; i < 1000; i++ {
+			ts.Start(e, Deploy)
+			ts.Stop(e, Deploy)
+		}
+
+		close(done)
+	}()
+
+	for i := 0; i < 1000; i++ {
+		ts.IsRunning(e, Deploy)
+		_, err := json.Marshal(ts)
+		c.Assert(err, IsNil)
+	}
+
+	<-done
+	c.Assert(ts.IsRunning(e, Deploy), Equals, false)
+}
+
+func (s *CoreSuite) TestTaskStatus_TryStart(c *C) {
+	e := &Environment{Name: "foo"}
+	ts := TaskStatus{}
+
+	ts.Start(e, Deploy)
+	c.Assert(ts.TryStart(e, Reconcile, Deploy), Equals, false)
+	c.Assert(ts.IsRunning(e, Reconcile), Equals, false)
+
+	ts.Stop(e, Deploy)
+	c.Assert(ts.TryStart(e, Reconcile, Deploy), Equals, true)
+	c.Assert(ts.IsRunning(e, Reconcile), Equals, true)
+}
+
+func (s *CoreSuite) TestTaskStatus_StartAfter(c *C) {
+	e := &Environment{Name: "foo"}
+	ts := TaskStatus{}
+	ts.Start(e, Reconcile)
+
+	started := make(chan bool)
+	go func() {
+		ts.StartAfter(e, Deploy, Reconcile)
+		close(started)
+	}()
+
+	select {
+	case <-started:
+		c.Fatal("deploy started while reconciling")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	ts.Stop(e, Reconcile)
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		c.Fatal("deploy not started after the reconciliation")
+	}
+
+	c.Assert(ts.IsRunning(e, Deploy), Equals, true)
+}
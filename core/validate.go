@@ -0,0 +1,50 @@
+package core
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// CheckPort returns an error if the port is malformed, the format is
+// <host-addr>:<host-port>:<container-port>/<proto>[@<environment>]
+func CheckPort(port string) error {
+	_, host, err := (&Docker{}).formatPort(port)
+	if err != nil {
+		return err
+	}
+
+	if _, err := strconv.Atoi(host.HostPort); host.HostPort != "" && err != nil {
+		return fmt.Errorf("Malformed port %q, invalid host port", port)
+	}
+
+	return nil
+}
+
+// CheckRestart returns an error if the restart policy is malformed
+func CheckRestart(restart string) error {
+	_, err := (&Docker{}).formatRestartPolicy(restart)
+	return err
+}
+
+// HostPorts returns the host ports bound by the project at the environment,
+// as <host-addr>:<host-port>/<proto>
+func (p *Project) HostPorts(e *Environment) []string {
+	var r []string
+	d := &Docker{env: e}
+	for _, port := range p.Ports {
+		guest, host, err := d.formatPort(port)
+		if err != nil || guest == "" || host.HostPort == "" {
+			continue
+		}
+
+		proto := "tcp"
+		if parts := strings.SplitN(string(guest), "/", 2); len(parts) == 2 {
+			proto = parts[1]
+		}
+
+		r = append(r, fmt.Sprintf("%s:%s/%s", host.HostIP, host.HostPort, proto))
+	}
+
+	return r
+}
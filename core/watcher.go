@@ -0,0 +1,180 @@
+package core
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsouza/go-dockerclient"
+)
+
+var EventContainer Event = "container"
+
+var ErrEventsClosed = errors.New("Docker events stream closed")
+
+var (
+	WatcherMinBackoff = 1 * time.Second
+	WatcherMaxBackoff = 1 * time.Minute
+)
+
+var watchedEvents = []string{"die", "oom", "start", "stop", "destroy", "health_status"}
+
+type ContainerEvent struct {
+	Project        string
+	Environment    string
+	DockerEndPoint string
+	Container      string
+	Image          ImageID
+	Status         string
+	Time           time.Time
+}
+
+// Watcher listens to the events of every docker end point, the events from
+// containers belonging to a project are triggered as EventContainer with a
+// *ContainerEvent as context
+type Watcher struct {
+	projects map[string]*Project
+	stop     chan bool
+	sync.WaitGroup
+}
+
+func NewWatcher(projects map[string]*Project) *Watcher {
+	return &Watcher{projects: projects}
+}
+
+func (w *Watcher) Start() {
+	w.stop = make(chan bool)
+	for endPoint, envs := range w.getEndPoints() {
+		w.Add(1)
+		go func(endPoint string, envs []*Environment) {
+			defer w.Done()
+			w.watch(endPoint, envs)
+		}(endPoint, envs)
+	}
+}
+
+func (w *Watcher) Stop() {
+	close(w.stop)
+	w.Wait()
+}
+
+// getEndPoints returns the environments using each docker end point
+func (w *Watcher) getEndPoints() map[string][]*Environment {
+	r := make(map[string][]*Environment, 0)
+	seen := make(map[*Environment]bool, 0)
+	for _, p := range w.projects {
+		for _, e := range p.Environments {
+			if seen[e] {
+				continue
+			}
+
+			seen[e] = true
+			for _, endPoint := range e.DockerEndPoints {
+				r[endPoint] = append(r[endPoint], e)
+			}
+		}
+	}
+
+	return r
+}
+
+func (w *Watcher) watch(endPoint string, envs []*Environment) {
+	backoff := WatcherMinBackoff
+	for {
+		connected, err := w.listen(endPoint, envs)
+		if connected {
+			backoff = WatcherMinBackoff
+		}
+
+		if err != nil {
+			Warning("Docker events connection lost", "end-point", endPoint, "error", err, "retry", backoff)
+		}
+
+		select {
+		case <-w.stop:
+			return
+		case <-time.After(backoff):
+		}
+
+		if backoff *= 2; backoff > WatcherMaxBackoff {
+			backoff = WatcherMaxBackoff
+		}
+	}
+}
+
+func (w *Watcher) listen(endPoint string, envs []*Environment) (bool, error) {
+	d, err := NewDocker(endPoint, envs[0])
+	if err != nil {
+		return false, err
+	}
+
+	events := make(chan *docker.APIEvents, 10)
+	if err := d.client.AddEventListener(events); err != nil {
+		return false, err
+	}
+
+	defer d.client.RemoveEventListener(events)
+	Debug("Listening docker events", "end-point", endPoint)
+
+	for {
+		select {
+		case <-w.stop:
+			return true, nil
+		case event, ok := <-events:
+			if !ok {
+				return true, ErrEventsClosed
+			}
+
+			if ce := w.newContainerEvent(endPoint, envs, event); ce != nil {
+				Events.Trigger(EventContainer, ce)
+			}
+		}
+	}
+}
+
+func (w *Watcher) newContainerEvent(endPoint string, envs []*Environment, event *docker.APIEvents) *ContainerEvent {
+	if !isWatchedEvent(event.Status) {
+		return nil
+	}
+
+	c := &Container{
+		DockerEndPoint: endPoint,
+		Image:          ImageID(event.From),
+		APIContainers:  docker.APIContainers{ID: event.ID, Image: event.From},
+	}
+
+	for _, p := range w.projects {
+		if !c.BelongsTo(p) {
+			continue
+		}
+
+		for _, e := range envs {
+			if p.Environments[e.Name] != e {
+				continue
+			}
+
+			return &ContainerEvent{
+				Project:        p.Name,
+				Environment:    e.Name,
+				DockerEndPoint: endPoint,
+				Container:      c.GetShortID(),
+				Image:          c.Image,
+				Status:         event.Status,
+				Time:           time.Unix(event.Time, 0),
+			}
+		}
+	}
+
+	return nil
+}
+
+func isWatchedEvent(status string) bool {
+	for _, e := range watchedEvents {
+		if status == e || strings.HasPrefix(status, e+":") {
+			return true
+		}
+	}
+
+	return false
+}
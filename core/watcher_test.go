@@ -0,0 +1,54 @@
+package core
+
+import (
+	"github.com/fsouza/go-dockerclient"
+	. "gopkg.in/check.v1"
+)
+
+func (s *CoreSuite) TestWatcher_getEndPoints(c *C) {
+	a := &Environment{Name: "a", DockerEndPoints: []string{"tcp://foo", "tcp://bar"}}
+	b := &Environment{Name: "b", DockerEndPoints: []string{"tcp://qux"}}
+
+	w := NewWatcher(map[string]*Project{
+		"foo": &Project{Name: "foo", Environments: map[string]*Environment{"a": a}},
+		"bar": &Project{Name: "bar", Environments: map[string]*Environment{"a": a, "b": b}},
+	})
+
+	r := w.getEndPoints()
+	c.Assert(r, HasLen, 3)
+	c.Assert(r["tcp://foo"], DeepEquals, []*Environment{a})
+	c.Assert(r["tcp://qux"], DeepEquals, []*Environment{b})
+}
+
+func (s *CoreSuite) TestWatcher_newContainerEvent(c *C) {
+	e := &Environment{Name: "a", DockerEndPoints: []string{"tcp://foo"}}
+	w := NewWatcher(map[string]*Project{
+		"foo": &Project{Name: "foo", Environments: map[string]*Environment{"a": e}},
+		"bar": &Project{Name: "bar", Environments: map[string]*Environment{"b": &Environment{Name: "b"}}},
+	})
+
+	ce := w.newContainerEvent("tcp://foo", []*Environment{e}, &docker.APIEvents{
+		Status: "die", ID: "123456789123456789", From: "foo:qux", Time: 42,
+	})
+
+	c.Assert(ce, NotNil)
+	c.Assert(ce.Project, Equals, "foo")
+	c.Assert(ce.Environment, Equals, "a")
+	c.Assert(ce.DockerEndPoint, Equals, "tcp://foo")
+	c.Assert(ce.Container, Equals, "123456789123")
+	c.Assert(ce.Status, Equals, "die")
+	c.Assert(ce.Time.Unix(), Equals, int64(42))
+
+	ce = w.newContainerEvent("tcp://foo", []*Environment{e}, &docker.APIEvents{Status: "die", From: "bar:qux"})
+	c.Assert(ce, IsNil)
+
+	ce = w.newContainerEvent("tcp://foo", []*Environment{e}, &docker.APIEvents{Status: "pull", From: "foo:qux"})
+	c.Assert(ce, IsNil)
+}
+
+func (s *CoreSuite) TestIsWatchedEvent(c *C) {
+	c.Assert(isWatchedEvent("oom"), Equals, true)
+	c.Assert(isWatchedEvent("health_status: unhealthy"), Equals, true)
+	c.Assert(isWatchedEvent("create"), Equals, false)
+	c.Assert(isWatchedEvent("stopped"), Equals, false)
+}
@@ -0,0 +1,131 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/mcuadros/dockership/config"
+	"github.com/mcuadros/dockership/core"
+	"github.com/mcuadros/dockership/store"
+
+	"gopkg.in/igm/sockjs-go.v2/sockjs"
+)
+
+var ErrForbidden = errors.New("Permission denied")
+
+// ProjectResult is a project with its effective config at the environments
+// where it is overridden
+type ProjectResult struct {
+	*core.Project
+	Effective map[string]*core.Project `json:",omitempty"`
+}
+
+type UserResult struct {
+	*User
+	Permissions map[string]map[string]config.Role
+}
+
+func (s *server) getRole(user *User, project, environment string) config.Role {
+	if user == nil {
+		return s.config().GetRole("", nil, project, environment)
+	}
+
+	if user.token != nil {
+		return getTokenRole(user.token, project, environment)
+	}
+
+	return s.config().GetRole(user.Login, user.Teams, project, environment)
+}
+
+// getTokenRole returns the role of the token, API tokens are not restricted by
+// the grants, only by its own scope
+func getTokenRole(t *store.Token, project, environment string) config.Role {
+	if !t.Match(project, environment) {
+		return config.RoleNone
+	}
+
+	role, _ := config.ParseRole(t.Role)
+	return role
+}
+
+func (s *server) can(user *User, role config.Role, project, environment string) bool {
+	return s.getRole(user, project, environment) >= role
+}
+
+// canView returns true if the user is at least viewer at any environment of
+// the project
+func (s *server) canView(user *User, project string) bool {
+	p, ok := s.config().Projects[project]
+	if !ok {
+		return false
+	}
+
+	for name := range p.Environments {
+		if s.can(user, config.RoleViewer, project, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isAdmin returns true if the user is admin at every project and environment
+func (s *server) isAdmin(user *User) bool {
+	return s.can(user, config.RoleAdmin, "*", "*")
+}
+
+func (s *server) getPermissions(user *User) map[string]map[string]config.Role {
+	r := make(map[string]map[string]config.Role, 0)
+	for name, p := range s.config().Projects {
+		r[name] = make(map[string]config.Role, 0)
+		for env := range p.Environments {
+			r[name][env] = s.getRole(user, name, env)
+		}
+	}
+
+	return r
+}
+
+func (s *server) getVisibleProjects(user *User) map[string]*ProjectResult {
+	r := make(map[string]*ProjectResult, 0)
+	for name, p := range s.config().Projects {
+		if !s.canView(user, name) {
+			continue
+		}
+
+		r[name] = &ProjectResult{Project: p}
+		for env := range p.Overrides {
+			if r[name].Effective == nil {
+				r[name].Effective = make(map[string]*core.Project, 0)
+			}
+
+			r[name].Effective[env] = p.ForEnvironment(env)
+		}
+	}
+
+	return r
+}
+
+// viewFilter accepts the sessions of the users able to view the project
+func (s *server) viewFilter(project string) SockJSFilter {
+	return func(session sockjs.Session) bool {
+		return s.canView(s.oauth.getSessionUser(session), project)
+	}
+}
+
+func (s *server) adminFilter(session sockjs.Session) bool {
+	return s.isAdmin(s.oauth.getSessionUser(session))
+}
+
+func (s *server) forbidden(session sockjs.Session, user *User, request string) {
+	core.Warning("Permission denied", "request", request, "user", user)
+	s.sockjs.SendTo(session, "error", map[string]string{
+		"request": request,
+		"error":   ErrForbidden.Error(),
+	}, false)
+}
+
+func (s *server) forbiddenRequest(w http.ResponseWriter, r *http.Request, user *User) {
+	core.Warning("Permission denied", "request", r.URL.Path, "user", user)
+	s.jsonError(w, http.StatusForbidden, ErrForbidden)
+}
@@ -0,0 +1,150 @@
+package http
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mcuadros/dockership/config"
+	"github.com/mcuadros/dockership/store"
+
+	. "gopkg.in/check.v1"
+)
+
+const grantsConfig = `
+[Environment "testing"]
+DockerEndPoint = http://testing:4243
+
+[Environment "live"]
+DockerEndPoint = http://live:4243
+
+[Project "a"]
+Repository = git@github.com:my-company/a.git
+Environment = testing
+Environment = live
+
+[Project "b"]
+Repository = git@github.com:my-company/b.git
+Environment = live
+
+[Grant "developers"]
+Role = deployer
+Team = my-company/developers
+Project = a
+Environment = testing
+
+[Grant "viewers"]
+Role = viewer
+Team = my-company/developers
+Project = a
+
+[Grant "ops"]
+Role = admin
+Team = my-company/ops
+`
+
+var (
+	developer = &User{Login: "foo", Teams: []string{"my-company/developers"}}
+	operator  = &User{Login: "bar", Teams: []string{"my-company/ops"}}
+)
+
+func newTestServer(c *C, withStore bool) *server {
+	f, err := ioutil.TempFile("", "dockership")
+	c.Assert(err, IsNil)
+	defer os.Remove(f.Name())
+
+	f.WriteString(grantsConfig)
+	f.Close()
+
+	cfg := &config.Config{}
+	c.Assert(cfg.LoadFile(f.Name()), IsNil)
+
+	s := &server{}
+	s.cfg.Store(cfg)
+	s.oauth = NewOAuth(s.config)
+
+	if withStore {
+		s.store, err = store.Open(filepath.Join(c.MkDir(), "dockership.db"))
+		c.Assert(err, IsNil)
+	}
+
+	s.auditor = &auditor{store: s.store}
+	return s
+}
+
+func newRequest(method, path string, user *User, form url.Values) *http.Request {
+	r, _ := http.NewRequest(method, path, strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if user != nil {
+		r = r.WithContext(context.WithValue(r.Context(), userKey{}, user))
+	}
+
+	return r
+}
+
+func (s *HTTPSuite) TestServer_can(c *C) {
+	srv := newTestServer(c, false)
+
+	c.Assert(srv.getRole(nil, "a", "testing"), Equals, config.RoleNone)
+	c.Assert(srv.getRole(developer, "a", "testing"), Equals, config.RoleDeployer)
+	c.Assert(srv.getRole(developer, "a", "live"), Equals, config.RoleViewer)
+	c.Assert(srv.getRole(developer, "b", "live"), Equals, config.RoleNone)
+	c.Assert(srv.getRole(operator, "b", "live"), Equals, config.RoleAdmin)
+
+	c.Assert(srv.can(developer, config.RoleDeployer, "a", "testing"), Equals, true)
+	c.Assert(srv.can(developer, config.RoleDeployer, "a", "live"), Equals, false)
+	c.Assert(srv.can(developer, config.RoleViewer, "a", "live"), Equals, true)
+	c.Assert(srv.can(nil, config.RoleViewer, "a", "live"), Equals, false)
+
+	c.Assert(srv.isAdmin(developer), Equals, false)
+	c.Assert(srv.isAdmin(operator), Equals, true)
+	c.Assert(srv.canView(developer, "b"), Equals, false)
+	c.Assert(srv.canView(developer, "qux"), Equals, false)
+}
+
+func (s *HTTPSuite) TestServer_getVisibleProjects(c *C) {
+	srv := newTestServer(c, false)
+
+	c.Assert(srv.getVisibleProjects(nil), HasLen, 0)
+
+	projects := srv.getVisibleProjects(developer)
+	c.Assert(projects, HasLen, 1)
+	c.Assert(projects["a"], NotNil)
+
+	c.Assert(srv.getVisibleProjects(operator), HasLen, 2)
+}
+
+func (s *HTTPSuite) TestServer_getPermissions(c *C) {
+	srv := newTestServer(c, false)
+
+	p := srv.getPermissions(developer)
+	c.Assert(p["a"]["testing"], Equals, config.RoleDeployer)
+	c.Assert(p["a"]["live"], Equals, config.RoleViewer)
+	c.Assert(p["b"]["live"], Equals, config.RoleNone)
+}
+
+func (s *HTTPSuite) TestServer_forbiddenRequest(c *C) {
+	srv := newTestServer(c, false)
+
+	w := httptest.NewRecorder()
+	srv.HandleAudit(w, newRequest("GET", "/rest/audit", developer, nil))
+	c.Assert(w.Code, Equals, http.StatusForbidden)
+	c.Assert(w.Body.String(), Equals, "{\"Error\":\"Permission denied\"}\n")
+
+	w = httptest.NewRecorder()
+	srv.HandleAudit(w, newRequest("GET", "/rest/audit", operator, nil))
+	c.Assert(w.Code, Equals, http.StatusServiceUnavailable)
+
+	w = httptest.NewRecorder()
+	srv.HandleRollback(w, newRequest("GET", "/rest/rollback/a/live", developer, nil), "a", "live")
+	c.Assert(w.Code, Equals, http.StatusForbidden)
+
+	w = httptest.NewRecorder()
+	srv.HandleRollback(w, newRequest("GET", "/rest/rollback/a/testing", nil, nil), "a", "testing")
+	c.Assert(w.Code, Equals, http.StatusForbidden)
+}
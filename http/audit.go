@@ -0,0 +1,209 @@
+package http
+
+import (
+	"encoding/json"
+	"io"
+	"log/syslog"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mcuadros/dockership/core"
+	"github.com/mcuadros/dockership/store"
+)
+
+const (
+	ActionDeploy            = "deploy"
+	ActionEnvironmentDeploy = "deploy-environment"
+	ActionTask              = "task"
+	ActionExec              = "exec"
+	ActionRollback          = "rollback"
+	ActionTokenCreate       = "token-create"
+	ActionTokenRevoke       = "token-revoke"
+	ActionConfigReload      = "config-reload"
+	ActionDefinitionPut     = "definition-put"
+	ActionDefinitionDelete  = "definition-delete"
+)
+
+// auditor records every user action at the store and, if configured, as JSON
+// lines at a file or syslog
+type auditor struct {
+	store   *store.Store
+	writers []io.Writer
+	sync.Mutex
+}
+
+func (s *server) configureAudit() {
+	s.auditor = &auditor{store: s.store}
+
+	if file := s.config().Audit.File; file != "" {
+		f, err := os.OpenFile(file, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+		if err != nil {
+			core.Error("Unable to open audit file", "file", file, "error", err)
+		} else {
+			s.auditor.writers = append(s.auditor.writers, f)
+		}
+	}
+
+	if s.config().Audit.Syslog {
+		w, err := syslog.New(syslog.LOG_INFO|syslog.LOG_AUTH, "dockership")
+		if err != nil {
+			core.Error("Unable to connect to syslog", "error", err)
+		} else {
+			s.auditor.writers = append(s.auditor.writers, w)
+		}
+	}
+}
+
+func (s *server) audit(r *http.Request, user *User, action string, params map[string]string, errs ...error) {
+	e := &store.AuditEntry{
+		Time:    time.Now(),
+		User:    user.String(),
+		IP:      getRemoteIP(r, s.config().HTTP.TrustedProxies),
+		Action:  action,
+		Params:  params,
+		Success: true,
+	}
+
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+
+		e.Success = false
+		if e.Error != "" {
+			e.Error += "; "
+		}
+
+		e.Error += err.Error()
+	}
+
+	s.auditor.Record(e)
+}
+
+func (a *auditor) Record(e *store.AuditEntry) {
+	a.Lock()
+	defer a.Unlock()
+
+	if a.store != nil {
+		if err := a.store.AddAuditEntry(e); err != nil {
+			core.Error("Unable to store audit entry", "action", e.Action, "user", e.User, "error", err)
+		}
+	}
+
+	if len(a.writers) == 0 {
+		return
+	}
+
+	raw, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+
+	raw = append(raw, '\n')
+	for _, w := range a.writers {
+		if _, err := w.Write(raw); err != nil {
+			core.Error("Unable to write audit entry", "action", e.Action, "user", e.User, "error", err)
+		}
+	}
+}
+
+func (s *server) HandleAudit(w http.ResponseWriter, r *http.Request) {
+	if user := s.oauth.getUser(r); !s.isAdmin(user) {
+		s.forbiddenRequest(w, r, user)
+		return
+	}
+
+	if s.store == nil {
+		s.jsonError(w, http.StatusServiceUnavailable, ErrStoreNotAvailable)
+		return
+	}
+
+	q := r.URL.Query()
+	f := &store.AuditFilter{User: q.Get("user"), Action: q.Get("action")}
+
+	var err error
+	if f.Since, err = parseTime(q.Get("since")); err != nil {
+		s.jsonError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if f.Until, err = parseTime(q.Get("until")); err != nil {
+		s.jsonError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if f.Limit, err = parseLimit(q.Get("limit")); err != nil {
+		s.jsonError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	l, err := s.store.FindAuditEntries(f)
+	if err != nil {
+		s.jsonError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if l == nil {
+		l = make([]*store.AuditEntry, 0)
+	}
+
+	s.json(w, http.StatusOK, l)
+}
+
+// getRemoteIP returns the IP of the client, the X-Forwarded-For header is only
+// honoured when the request comes from a trusted proxy, the closest address
+// not belonging to a trusted proxy is taken
+func getRemoteIP(r *http.Request, proxies []string) string {
+	if r == nil {
+		return ""
+	}
+
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+
+	if !isTrustedProxy(proxies, ip) {
+		return ip
+	}
+
+	forwarded := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		addr := strings.TrimSpace(forwarded[i])
+		if addr == "" {
+			continue
+		}
+
+		ip = addr
+		if !isTrustedProxy(proxies, ip) {
+			break
+		}
+	}
+
+	return ip
+}
+
+// isTrustedProxy returns true if the ip matches any of the proxies, given as
+// IP addresses or CIDR ranges
+func isTrustedProxy(proxies []string, ip string) bool {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+
+	for _, p := range proxies {
+		if _, network, err := net.ParseCIDR(p); err == nil {
+			if network.Contains(addr) {
+				return true
+			}
+		} else if proxy := net.ParseIP(p); proxy != nil && proxy.Equal(addr) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,78 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/mcuadros/dockership/store"
+
+	. "gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type HTTPSuite struct{}
+
+var _ = Suite(&HTTPSuite{})
+
+func (s *HTTPSuite) TestGetRemoteIP(c *C) {
+	r, _ := http.NewRequest("GET", "/rest/projects", nil)
+	r.RemoteAddr = "10.0.0.1:4242"
+	c.Assert(getRemoteIP(r, nil), Equals, "10.0.0.1")
+
+	r.Header.Set("X-Forwarded-For", "1.2.3.4")
+	c.Assert(getRemoteIP(r, nil), Equals, "10.0.0.1")
+	c.Assert(getRemoteIP(r, []string{"10.0.0.2"}), Equals, "10.0.0.1")
+	c.Assert(getRemoteIP(r, []string{"10.0.0.1"}), Equals, "1.2.3.4")
+	c.Assert(getRemoteIP(r, []string{"10.0.0.0/8"}), Equals, "1.2.3.4")
+
+	r.Header.Set("X-Forwarded-For", "6.6.6.6, 1.2.3.4, 10.0.0.3")
+	c.Assert(getRemoteIP(r, []string{"10.0.0.0/8"}), Equals, "1.2.3.4")
+
+	c.Assert(getRemoteIP(nil, nil), Equals, "")
+}
+
+func (s *HTTPSuite) TestServer_audit(c *C) {
+	srv := newTestServer(c, true)
+	defer srv.store.Close()
+
+	r := newRequest("GET", "/rest/deploy/a/live", operator, nil)
+	r.RemoteAddr = "10.0.0.1:4242"
+	srv.audit(r, operator, ActionDeploy, map[string]string{"project": "a"}, nil, errors.New("foo"))
+
+	l, err := srv.store.FindAuditEntries(&store.AuditFilter{Action: ActionDeploy})
+	c.Assert(err, IsNil)
+	c.Assert(l, HasLen, 1)
+	c.Assert(l[0].User, Equals, operator.String())
+	c.Assert(l[0].IP, Equals, "10.0.0.1")
+	c.Assert(l[0].Params["project"], Equals, "a")
+	c.Assert(l[0].Success, Equals, false)
+	c.Assert(l[0].Error, Equals, "foo")
+
+	w := httptest.NewRecorder()
+	srv.HandleAudit(w, newRequest("GET", "/rest/audit?action=deploy", operator, nil))
+	c.Assert(w.Code, Equals, http.StatusOK)
+	c.Assert(w.Body.String(), Matches, `(?s)\[\{.*"Action":"deploy".*\}\]\n`)
+}
+
+func (s *HTTPSuite) TestServer_reloadConfigBySystem(c *C) {
+	srv := newTestServer(c, true)
+	defer srv.store.Close()
+
+	defer func(previous string) { configFile = previous }(configFile)
+	configFile = filepath.Join(c.MkDir(), "missing.conf")
+
+	result := srv.reloadConfigBySystem("sighup")
+	c.Assert(result.Done, Equals, false)
+
+	l, err := srv.store.FindAuditEntries(&store.AuditFilter{Action: ActionConfigReload})
+	c.Assert(err, IsNil)
+	c.Assert(l, HasLen, 1)
+	c.Assert(l[0].User, Equals, "system")
+	c.Assert(l[0].IP, Equals, "")
+	c.Assert(l[0].Params["source"], Equals, "sighup")
+	c.Assert(l[0].Success, Equals, false)
+}
@@ -1,14 +1,28 @@
 package http
 
 import (
+	"github.com/mcuadros/dockership/core"
+
 	"gopkg.in/igm/sockjs-go.v2/sockjs"
 )
 
 func (s *server) EmitProjects(session sockjs.Session) {
-	s.sockjs.Send("projects", s.config.Projects, false)
+	s.sockjs.SendEach("projects", func(session sockjs.Session) interface{} {
+		return s.getVisibleProjects(s.oauth.getSessionUser(session))
+	})
 }
 
 func (s *server) EmitUser(session sockjs.Session) {
 	//user, _ := s.oauth.getUser(s.oauth.getToken(r))
 	//s.sockjs.Send("user", user, false)
 }
+
+func (s *server) EmitContainerEvent(ctx ...interface{}) {
+	e := ctx[0].(*core.ContainerEvent)
+	core.Info(
+		"Container event", "project", e.Project, "environment", e.Environment,
+		"container", e.Container, "end-point", e.DockerEndPoint, "status", e.Status,
+	)
+
+	s.sockjs.SendFiltered("container-event", e, false, s.viewFilter(e.Project))
+}
@@ -22,16 +22,26 @@ func (s *server) HandleContainers(msg Message, session sockjs.Session) {
 		return
 	}
 
-	s.sockjs.Send("containers", s.GetContainers(project), false)
+	user := s.oauth.getSessionUser(session)
+	if project != "" && !s.canView(user, project) {
+		s.forbidden(session, user, "containers")
+		return
+	}
+
+	s.sockjs.SendTo(session, "containers", s.GetContainers(user, project), false)
 }
 
-func (s *server) GetContainers(project string) []*ContainersRecord {
+func (s *server) GetContainers(user *User, project string) []*ContainersRecord {
 	var result []*ContainersRecord
-	for name, p := range s.config.Projects {
+	for name, p := range s.config().Projects {
 		if project != "" && project != name {
 			continue
 		}
 
+		if !s.canView(user, name) {
+			continue
+		}
+
 		l, err := p.ListContainers()
 		if len(err) != 0 {
 			result = append(result, &ContainersRecord{Project: p, Error: err})
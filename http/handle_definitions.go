@@ -0,0 +1,124 @@
+package http
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/mcuadros/dockership/config"
+	"github.com/mcuadros/dockership/core"
+
+	"github.com/gorilla/mux"
+)
+
+type DefinitionResult struct {
+	Done     bool
+	Errors   []string `json:",omitempty"`
+	Warnings []string `json:",omitempty"`
+}
+
+// HandlePutDefinition stores at etcd the JSON definition of a project or an
+// environment, the daemon applies it when etcd notifies the change
+func (s *server) HandlePutDefinition(w http.ResponseWriter, r *http.Request) {
+	kind, name := mux.Vars(r)["kind"], mux.Vars(r)["name"]
+	raw, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		s.jsonError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	s.handleDefinition(w, r, kind, name,
+		func(c *config.Config) error { return c.SetDefinition(kind, name, raw) },
+		func(c *config.Config) error { return c.PutDefinition(kind, name, raw) },
+	)
+}
+
+// HandleDeleteDefinition removes from etcd the definition of a project or an
+// environment
+func (s *server) HandleDeleteDefinition(w http.ResponseWriter, r *http.Request) {
+	kind, name := mux.Vars(r)["kind"], mux.Vars(r)["name"]
+	s.handleDefinition(w, r, kind, name,
+		func(c *config.Config) error { return c.DeleteDefinition(kind, name) },
+		func(c *config.Config) error { return c.RemoveDefinition(kind, name) },
+	)
+}
+
+// handleDefinition applies the change to a new copy of the config and, if it
+// is still valid, stores it at etcd. The projects can be changed by their
+// admins, the environments only by the unrestricted admins.
+func (s *server) handleDefinition(
+	w http.ResponseWriter, r *http.Request, kind, name string,
+	change, store func(c *config.Config) error,
+) {
+	user := s.oauth.getUser(r)
+	allowed := s.isAdmin(user)
+	if kind == config.DefinitionProject {
+		allowed = s.can(user, config.RoleAdmin, name, "*")
+	}
+
+	if !allowed {
+		s.forbiddenRequest(w, r, user)
+		return
+	}
+
+	action := ActionDefinitionPut
+	if r.Method == "DELETE" {
+		action = ActionDefinitionDelete
+	}
+
+	status, result := s.changeDefinition(change, store)
+	var errs []error
+	for _, err := range result.Errors {
+		errs = append(errs, errors.New(err))
+	}
+
+	s.audit(r, user, action, map[string]string{"kind": kind, "name": name}, errs...)
+	if result.Done {
+		core.Info("Definition stored at etcd", "kind", kind, "name", name, "user", user)
+	}
+
+	s.json(w, status, result)
+}
+
+func (s *server) changeDefinition(change, store func(c *config.Config) error) (int, *DefinitionResult) {
+	r := &DefinitionResult{}
+	fail := func(status int, errs ...string) (int, *DefinitionResult) {
+		r.Errors = errs
+		return status, r
+	}
+
+	c := &config.Config{}
+	if err := c.LoadFile(configFile); err != nil {
+		return fail(http.StatusInternalServerError, err.Error())
+	}
+
+	if err := change(c); err != nil {
+		status := http.StatusBadRequest
+		if err == config.ErrDefinitionNotFound {
+			status = http.StatusNotFound
+		}
+
+		return fail(status, err.Error())
+	}
+
+	problems := c.Validate()
+	for _, p := range problems.Warnings() {
+		r.Warnings = append(r.Warnings, p.Error())
+	}
+
+	if errs := problems.Errors(); len(errs) != 0 {
+		var msgs []string
+		for _, p := range errs {
+			msgs = append(msgs, p.Error())
+		}
+
+		return fail(http.StatusBadRequest, msgs...)
+	}
+
+	if err := store(c); err != nil {
+		return fail(http.StatusInternalServerError, err.Error())
+	}
+
+	r.Done = true
+	return http.StatusOK, r
+}
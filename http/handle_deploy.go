@@ -1,17 +1,26 @@
 package http
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
+	"net/http"
+	"strings"
 	"time"
 
+	"github.com/mcuadros/dockership/config"
 	"github.com/mcuadros/dockership/core"
 
 	"gopkg.in/igm/sockjs-go.v2/sockjs"
 )
 
-var ErrProjectNotFound = errors.New("Project not found")
+var (
+	ErrProjectNotFound     = errors.New("Project not found")
+	ErrEnvironmentNotFound = errors.New("Environment not found")
+	ErrNothingToDeploy     = errors.New("Nothing to deploy")
+)
 
 type DeployResult struct {
 	Done    bool
@@ -33,9 +42,60 @@ func (s *server) HandleDeploy(msg Message, session sockjs.Session) {
 		return
 	}
 
+	user := s.oauth.getSessionUser(session)
+	if !s.can(user, config.RoleDeployer, project, environment) {
+		s.forbidden(session, user, "deploy")
+		return
+	}
+
+	go func(session sockjs.Session) {
+		time.Sleep(50 * time.Millisecond)
+		s.EmitProjects(session)
+	}(session)
+
+	result := s.DoDeploy(s.newDeployWriter(project, environment), user, project, environment, force)
+	s.auditDeploy(session.Request(), user, project, environment, result)
+	s.EmitProjects(session)
+}
+
+func (s *server) HandleEnvironmentDeploy(msg Message, session sockjs.Session) {
+	force := true
+	environment, ok := msg.Request["environment"]
+	if !ok {
+		core.Error("Missing environment", "request", "deploy-environment")
+		return
+	}
+
+	var projects []string
+	if list, ok := msg.Request["projects"]; ok && list != "" {
+		projects = strings.Split(list, ",")
+	}
+
+	user := s.oauth.getSessionUser(session)
+	if !s.canDeployAll(user, projects, environment) {
+		s.forbidden(session, user, "deploy-environment")
+		return
+	}
+
+	go func(session sockjs.Session) {
+		time.Sleep(50 * time.Millisecond)
+		s.EmitProjects(session)
+	}(session)
+
+	result := s.DoEnvironmentDeploy(func(project string) io.Writer {
+		return s.newDeployWriter(project, environment)
+	}, user, projects, environment, force)
+
+	s.auditEnvironmentDeploy(session.Request(), user, projects, environment, result)
+	s.sockjs.SendTo(session, "deploy-environment", result, false)
+	s.EmitProjects(session)
+}
+
+func (s *server) newDeployWriter(project, environment string) io.Writer {
 	now := time.Now()
 
 	writer := NewSockJSWriter(s.sockjs, "deploy")
+	writer.SetFilter(s.viewFilter(project))
 	writer.SetFormater(func(raw []byte) []byte {
 		str, _ := json.Marshal(map[string]string{
 			"environment": environment,
@@ -47,16 +107,10 @@ func (s *server) HandleDeploy(msg Message, session sockjs.Session) {
 		return str
 	})
 
-	go func(session sockjs.Session) {
-		time.Sleep(50 * time.Millisecond)
-		s.EmitProjects(session)
-	}(session)
-
-	s.DoDeploy(writer, project, environment, force)
-	s.EmitProjects(session)
+	return writer
 }
 
-func (s *server) DoDeploy(w io.Writer, project, environment string, force bool) *DeployResult {
+func (s *server) DoDeploy(w io.Writer, user *User, project, environment string, force bool) *DeployResult {
 	start := time.Now()
 	r := &DeployResult{}
 	defer func() {
@@ -65,10 +119,10 @@ func (s *server) DoDeploy(w io.Writer, project, environment string, force bool)
 
 	core.Info(
 		"Starting deploy",
-		"project", project, "environment", environment, "force", force,
+		"project", project, "environment", environment, "force", force, "user", user,
 	)
 
-	p, ok := s.config.Projects[project]
+	p, ok := s.config().Projects[project]
 	if !ok {
 		core.Error("Project not found", "project", p)
 
@@ -76,7 +130,11 @@ func (s *server) DoDeploy(w io.Writer, project, environment string, force bool)
 		return r
 	}
 
-	r.Errors = p.Deploy(environment, w, force)
+	output := bytes.NewBuffer(nil)
+	report := p.DeployWithReport(environment, core.MultiEndPointWriter(w, output), force)
+	s.recordDeploy(user, p, environment, report, start, output.Bytes())
+
+	r.Errors = report.Errors
 	if len(r.Errors) == 0 {
 		r.Done = true
 		core.Info("Deploy success", "project", p, "environment", environment)
@@ -88,3 +146,143 @@ func (s *server) DoDeploy(w io.Writer, project, environment string, force bool)
 
 	return r
 }
+
+func (s *server) auditDeploy(r *http.Request, user *User, project, environment string, result *DeployResult) {
+	s.audit(r, user, ActionDeploy, map[string]string{
+		"project":     project,
+		"environment": environment,
+	}, result.Errors...)
+}
+
+type EnvironmentDeployResult struct {
+	Done     bool
+	Elapsed  time.Duration
+	Projects []string
+	Results  map[string]*DeployResult
+	Errors   []error `json:",omitempty"`
+}
+
+// DoEnvironmentDeploy deploys the given projects, or all the outdated ones if
+// none is given, following the links between them, so a project is deployed
+// after the projects it links to. If a deploy fails the projects linking to
+// it, directly or indirectly, are skipped.
+func (s *server) DoEnvironmentDeploy(
+	writer func(project string) io.Writer, user *User, projects []string, environment string, force bool,
+) *EnvironmentDeployResult {
+	start := time.Now()
+	r := &EnvironmentDeployResult{Results: make(map[string]*DeployResult, 0)}
+	defer func() {
+		r.Elapsed = time.Since(start)
+	}()
+
+	core.Info(
+		"Starting environment deploy",
+		"environment", environment, "projects", strings.Join(projects, ", "), "force", force, "user", user,
+	)
+
+	list, errs := s.getProjectsToDeploy(user, projects, environment)
+	if len(errs) != 0 {
+		r.Errors = errs
+		return r
+	}
+
+	if len(list) == 0 {
+		r.Errors = []error{ErrNothingToDeploy}
+		return r
+	}
+
+	sorted, err := core.SortByLinks(list)
+	if err != nil {
+		r.Errors = []error{err}
+		return r
+	}
+
+	failed := make(map[string]bool, 0)
+	for _, p := range sorted {
+		r.Projects = append(r.Projects, p.Name)
+		if name := getFailedLink(p, failed); name != "" {
+			core.Error("Skipping deploy, linked project failed", "project", p, "linked", name)
+
+			failed[p.Name] = true
+			r.Results[p.Name] = &DeployResult{Errors: []error{
+				fmt.Errorf("Skipped, linked project %q failed", name),
+			}}
+
+			continue
+		}
+
+		result := s.DoDeploy(writer(p.Name), user, p.Name, environment, force)
+		if !result.Done {
+			failed[p.Name] = true
+			r.Errors = append(r.Errors, result.Errors...)
+		}
+
+		r.Results[p.Name] = result
+	}
+
+	r.Done = len(failed) == 0
+	return r
+}
+
+func (s *server) auditEnvironmentDeploy(
+	r *http.Request, user *User, projects []string, environment string, result *EnvironmentDeployResult,
+) {
+	s.audit(r, user, ActionEnvironmentDeploy, map[string]string{
+		"environment": environment,
+		"projects":    strings.Join(result.Projects, ","),
+		"requested":   strings.Join(projects, ","),
+	}, result.Errors...)
+}
+
+// canDeployAll returns true if the user is deployer at the environment for all
+// the given projects, with no projects the check is done at deploy time, only
+// the allowed projects are deployed
+func (s *server) canDeployAll(user *User, projects []string, environment string) bool {
+	for _, name := range projects {
+		if !s.can(user, config.RoleDeployer, name, environment) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (s *server) getProjectsToDeploy(user *User, projects []string, environment string) ([]*core.Project, []error) {
+	if len(projects) == 0 {
+		all := make([]*core.Project, 0, len(s.config().Projects))
+		for name, p := range s.config().Projects {
+			if s.can(user, config.RoleDeployer, name, environment) {
+				all = append(all, p)
+			}
+		}
+
+		return core.OutdatedProjects(all, environment)
+	}
+
+	var r []*core.Project
+	for _, name := range projects {
+		p, ok := s.config().Projects[name]
+		if !ok {
+			core.Error("Project not found", "project", name)
+			return nil, []error{ErrProjectNotFound}
+		}
+
+		if !p.HasEnvironment(environment) {
+			return nil, []error{fmt.Errorf("Project %q is not deployable at %q", name, environment)}
+		}
+
+		r = append(r, p)
+	}
+
+	return r, nil
+}
+
+func getFailedLink(p *core.Project, failed map[string]bool) string {
+	for name := range p.Links {
+		if failed[name] {
+			return name
+		}
+	}
+
+	return ""
+}
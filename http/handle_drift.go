@@ -0,0 +1,48 @@
+package http
+
+import (
+	"github.com/mcuadros/dockership/config"
+	"github.com/mcuadros/dockership/core"
+)
+
+type DriftResult struct {
+	Project      *core.Project
+	Environments map[string][]*core.ContainerDrift
+	Errors       []error `json:",omitempty"`
+}
+
+func (s *server) GetDrift(user *User, project string) map[string]*DriftResult {
+	result := make(map[string]*DriftResult, 0)
+	for name, p := range s.config().Projects {
+		if project != "" && project != name {
+			continue
+		}
+
+		if !s.canView(user, name) {
+			continue
+		}
+
+		record := &DriftResult{
+			Project:      p,
+			Environments: make(map[string][]*core.ContainerDrift, 0),
+		}
+
+		for _, e := range p.Environments {
+			if !s.can(user, config.RoleViewer, name, e.Name) {
+				continue
+			}
+
+			l, errs := p.DetectDrift(e)
+			for _, err := range errs {
+				core.Error(err.Error(), "project", p, "environment", e)
+			}
+
+			record.Errors = append(record.Errors, errs...)
+			record.Environments[e.Name] = l
+		}
+
+		result[name] = record
+	}
+
+	return result
+}
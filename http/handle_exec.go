@@ -0,0 +1,184 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mcuadros/dockership/config"
+	"github.com/mcuadros/dockership/core"
+
+	"gopkg.in/igm/sockjs-go.v2/sockjs"
+)
+
+var ErrExecNotAllowed = errors.New("User not allowed to exec into containers")
+
+// ExecMessage is the message exchanged over an exec session. The first message
+// sent by the client must be a "start" one, after it "stdin" and "resize"
+// messages are accepted. The server sends "stdout", "error" and "exit"
+// messages.
+type ExecMessage struct {
+	Type        string
+	Project     string `json:",omitempty"`
+	Environment string `json:",omitempty"`
+	EndPoint    string `json:",omitempty"`
+	Command     string `json:",omitempty"`
+	Data        string `json:",omitempty"`
+	Width       int    `json:",omitempty"`
+	Height      int    `json:",omitempty"`
+	ExitCode    int
+}
+
+func (s *server) HandleExecSession(session sockjs.Session) {
+	defer session.Close(0, "")
+
+	user := s.oauth.getSessionUser(session)
+	start, err := recvExecMessage(session)
+	if err != nil || start.Type != "start" {
+		sendExecMessage(session, &ExecMessage{Type: "error", Data: "Expected start message"})
+		return
+	}
+
+	params := map[string]string{
+		"project":     start.Project,
+		"environment": start.Environment,
+		"endpoint":    start.EndPoint,
+		"command":     start.Command,
+	}
+
+	if !s.isExecAllowed(user, start.Project, start.Environment) {
+		core.Warning("Exec session rejected", "user", user)
+		s.audit(session.Request(), user, ActionExec, params, ErrExecNotAllowed)
+		sendExecMessage(session, &ExecMessage{Type: "error", Data: ErrExecNotAllowed.Error()})
+		return
+	}
+
+	p, ok := s.config().Projects[start.Project]
+	if !ok {
+		s.audit(session.Request(), user, ActionExec, params, ErrProjectNotFound)
+		sendExecMessage(session, &ExecMessage{Type: "error", Data: ErrProjectNotFound.Error()})
+		return
+	}
+
+	exec, err := p.NewExec(start.Environment, start.EndPoint, strings.Fields(start.Command))
+	if err != nil {
+		core.Error(err.Error(), "project", p, "environment", start.Environment, "user", user)
+		s.audit(session.Request(), user, ActionExec, params, err)
+		sendExecMessage(session, &ExecMessage{Type: "error", Data: err.Error()})
+		return
+	}
+
+	core.Info(
+		"Exec session started",
+		"project", p, "environment", start.Environment, "container", exec,
+		"command", strings.Join(exec.Command, " "), "user", user,
+	)
+
+	params["container"] = exec.String()
+	params["state"] = "started"
+	s.audit(session.Request(), user, ActionExec, params)
+
+	started := time.Now()
+	input, stdin := io.Pipe()
+	go s.readExecSession(session, exec, stdin)
+
+	code, err := exec.Start(input, &execSessionWriter{session}, start.Height, start.Width)
+	input.Close()
+
+	if err != nil {
+		sendExecMessage(session, &ExecMessage{Type: "error", Data: err.Error()})
+	} else {
+		sendExecMessage(session, &ExecMessage{Type: "exit", ExitCode: code})
+	}
+
+	core.Info(
+		"Exec session finished",
+		"project", p, "environment", start.Environment, "container", exec,
+		"user", user, "exit-code", code, "elapsed", time.Since(started),
+	)
+
+	params["state"] = "finished"
+	params["exit-code"] = strconv.Itoa(code)
+	s.audit(session.Request(), user, ActionExec, params, err)
+}
+
+func (s *server) readExecSession(session sockjs.Session, exec *core.Exec, stdin *io.PipeWriter) {
+	defer stdin.Close()
+
+	for {
+		msg, err := recvExecMessage(session)
+		if err != nil {
+			return
+		}
+
+		switch msg.Type {
+		case "stdin":
+			if _, err := io.WriteString(stdin, msg.Data); err != nil {
+				return
+			}
+		case "resize":
+			if err := exec.Resize(msg.Height, msg.Width); err != nil {
+				core.Warning("Unable to resize exec", "container", exec, "err", err)
+			}
+		}
+	}
+}
+
+// isExecAllowed returns true for the users at HTTP.ExecUsers and, when grants
+// are defined, for the admins of the project at the environment
+func (s *server) isExecAllowed(user *User, project, environment string) bool {
+	if user == nil {
+		return false
+	}
+
+	for _, login := range s.config().HTTP.ExecUsers {
+		if login == user.Login {
+			return true
+		}
+	}
+
+	if len(s.config().Grants) == 0 {
+		return false
+	}
+
+	return s.can(user, config.RoleAdmin, project, environment)
+}
+
+func recvExecMessage(session sockjs.Session) (*ExecMessage, error) {
+	raw, err := session.Recv()
+	if err != nil {
+		return nil, err
+	}
+
+	msg := &ExecMessage{}
+	if err := json.Unmarshal([]byte(raw), msg); err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
+
+func sendExecMessage(session sockjs.Session, msg *ExecMessage) error {
+	raw, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	return session.Send(string(raw))
+}
+
+type execSessionWriter struct {
+	session sockjs.Session
+}
+
+func (w *execSessionWriter) Write(raw []byte) (int, error) {
+	err := sendExecMessage(w.session, &ExecMessage{Type: "stdout", Data: string(raw)})
+	if err != nil {
+		return 0, err
+	}
+
+	return len(raw), nil
+}
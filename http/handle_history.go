@@ -0,0 +1,221 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mcuadros/dockership/config"
+	"github.com/mcuadros/dockership/core"
+	"github.com/mcuadros/dockership/store"
+)
+
+var (
+	ErrStoreNotAvailable = errors.New("Store not available")
+	ErrInvalidDate       = errors.New("Invalid date, a unix timestamp or a RFC3339 date is expected")
+	ErrInvalidLimit      = errors.New("Invalid limit, a number is expected")
+)
+
+func (s *server) openStore() {
+	st, err := store.Open(s.config().Global.Database)
+	if err != nil {
+		core.Error("Unable to open the store, history disabled", "database", s.config().Global.Database, "error", err)
+		return
+	}
+
+	s.store = st
+	s.loadDesiredStates()
+}
+
+// loadDesiredStates restores the desired states stored before the restart, so
+// the reconciler doesn't fall back to the newest container
+func (s *server) loadDesiredStates() {
+	states, err := s.store.FindDesiredStates()
+	if err != nil {
+		core.Error("Unable to load the desired states", "error", err)
+		return
+	}
+
+	core.States.Load(states)
+}
+
+func (s *server) recordDesiredState(ctx ...interface{}) {
+	if s.store == nil {
+		return
+	}
+
+	state := ctx[0].(*core.DesiredState)
+	if err := s.store.SetDesiredState(state); err != nil {
+		core.Error("Unable to record desired state", "project", state.Project, "environment", state.Environment, "error", err)
+	}
+}
+
+func (s *server) recordDeploy(
+	user *User, p *core.Project, environment string, r *core.DeployReport, start time.Time, output []byte,
+) {
+	if s.store == nil {
+		return
+	}
+
+	d := store.NewDeploy(p.Name, environment, user.String(), r)
+	d.Start = start
+	d.End = time.Now()
+
+	if err := s.store.AddDeploy(d, output); err != nil {
+		core.Error("Unable to record deploy", "project", p, "environment", environment, "error", err)
+	}
+}
+
+func (s *server) HandleHistory(w http.ResponseWriter, r *http.Request) {
+	if s.store == nil {
+		s.jsonError(w, http.StatusServiceUnavailable, ErrStoreNotAvailable)
+		return
+	}
+
+	f, err := parseDeployFilter(r)
+	if err != nil {
+		s.jsonError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	limit := f.Limit
+	f.Limit = 0
+
+	all, err := s.store.FindDeploys(f)
+	if err != nil {
+		s.jsonError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	user := s.oauth.getUser(r)
+	l := make([]*store.Deploy, 0)
+	for _, d := range all {
+		if limit > 0 && len(l) >= limit {
+			break
+		}
+
+		if s.can(user, config.RoleViewer, d.Project, d.Environment) {
+			l = append(l, d)
+		}
+	}
+
+	s.json(w, http.StatusOK, l)
+}
+
+func (s *server) HandleHistoryDeploy(w http.ResponseWriter, r *http.Request, id string) {
+	d, err := s.getDeploy(id)
+	if err != nil {
+		s.jsonError(w, getStoreErrorStatus(err), err)
+		return
+	}
+
+	if user := s.oauth.getUser(r); !s.can(user, config.RoleViewer, d.Project, d.Environment) {
+		s.forbiddenRequest(w, r, user)
+		return
+	}
+
+	s.json(w, http.StatusOK, d)
+}
+
+func (s *server) HandleHistoryLog(w http.ResponseWriter, r *http.Request, id string) {
+	d, err := s.getDeploy(id)
+	if err != nil {
+		s.jsonError(w, getStoreErrorStatus(err), err)
+		return
+	}
+
+	if user := s.oauth.getUser(r); !s.can(user, config.RoleViewer, d.Project, d.Environment) {
+		s.forbiddenRequest(w, r, user)
+		return
+	}
+
+	output, err := s.store.GetDeployLog(d.ID)
+	if err != nil {
+		s.jsonError(w, getStoreErrorStatus(err), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write(output)
+}
+
+func (s *server) getDeploy(id string) (*store.Deploy, error) {
+	if s.store == nil {
+		return nil, ErrStoreNotAvailable
+	}
+
+	n, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return nil, store.ErrNotFound
+	}
+
+	return s.store.GetDeploy(n)
+}
+
+func getStoreErrorStatus(err error) int {
+	switch err {
+	case ErrStoreNotAvailable:
+		return http.StatusServiceUnavailable
+	case store.ErrNotFound:
+		return http.StatusNotFound
+	}
+
+	return http.StatusInternalServerError
+}
+
+// parseDeployFilter reads the project, environment, user, since, until and
+// limit query parameters, the dates are RFC3339 or unix timestamps
+func parseDeployFilter(r *http.Request) (*store.DeployFilter, error) {
+	q := r.URL.Query()
+	f := &store.DeployFilter{
+		Project:     q.Get("project"),
+		Environment: q.Get("environment"),
+		User:        q.Get("user"),
+	}
+
+	var err error
+	if f.Since, err = parseTime(q.Get("since")); err != nil {
+		return nil, err
+	}
+
+	if f.Until, err = parseTime(q.Get("until")); err != nil {
+		return nil, err
+	}
+
+	if f.Limit, err = parseLimit(q.Get("limit")); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func parseLimit(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	limit, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, ErrInvalidLimit
+	}
+
+	return limit, nil
+}
+
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if ts, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(ts, 0), nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, ErrInvalidDate
+	}
+
+	return t, nil
+}
@@ -0,0 +1,159 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mcuadros/dockership/config"
+	"github.com/mcuadros/dockership/core"
+
+	"gopkg.in/igm/sockjs-go.v2/sockjs"
+)
+
+var (
+	ErrInvalidTail  = errors.New("Invalid tail, a number or \"all\" is expected")
+	ErrInvalidSince = errors.New("Invalid since, a unix timestamp or a RFC3339 date is expected")
+)
+
+type LogsResult struct {
+	Done    bool
+	Elapsed time.Duration
+	Errors  []error `json:",omitempty"`
+}
+
+func (s *server) HandleContainersLogs(msg Message, session sockjs.Session) {
+	project, ok := msg.Request["project"]
+	if !ok {
+		core.Error("Missing project", "request", "containers/logs")
+		return
+	}
+
+	environment, ok := msg.Request["environment"]
+	if !ok {
+		core.Error("Missing environment", "request", "containers/logs")
+		return
+	}
+
+	user := s.oauth.getSessionUser(session)
+	if !s.can(user, config.RoleViewer, project, environment) {
+		s.forbidden(session, user, "containers/logs")
+		return
+	}
+
+	opts, err := parseLogsOptions(func(key string) string {
+		return msg.Request[key]
+	})
+
+	opts.Context = s.sockjs.Context(session)
+
+	var result *LogsResult
+	if err != nil {
+		result = &LogsResult{Errors: []error{err}}
+	} else {
+		result = s.DoLogs(project, environment, opts, func(l *core.LogLine) error {
+			return s.sockjs.SendTo(session, "containers/logs", l, false)
+		})
+	}
+
+	s.sockjs.SendTo(session, "containers/logs-result", map[string]interface{}{
+		"project":     project,
+		"environment": environment,
+		"result":      result,
+	}, false)
+}
+
+// HandleLogsRequest streams the log lines as JSON, one per line, the last
+// line is the LogsResult.
+func (s *server) HandleLogsRequest(w http.ResponseWriter, r *http.Request, project, environment string) {
+	user := s.oauth.getUser(r)
+	if !s.can(user, config.RoleViewer, project, environment) {
+		s.forbiddenRequest(w, r, user)
+		return
+	}
+
+	opts, err := parseLogsOptions(r.URL.Query().Get)
+	if err != nil {
+		s.json(w, http.StatusBadRequest, &LogsResult{Errors: []error{err}})
+		return
+	}
+
+	opts.Context = r.Context()
+
+	flusher, _ := w.(http.Flusher)
+	w.Header().Set("Content-Type", "application/x-ndjson; charset=utf-8")
+	encoder := json.NewEncoder(w)
+
+	result := s.DoLogs(project, environment, opts, func(l *core.LogLine) error {
+		select {
+		case <-r.Context().Done():
+			return core.ErrLogsClosed
+		default:
+		}
+
+		if err := encoder.Encode(l); err != nil {
+			return err
+		}
+
+		if flusher != nil {
+			flusher.Flush()
+		}
+
+		return nil
+	})
+
+	encoder.Encode(result)
+}
+
+func (s *server) DoLogs(project, environment string, opts core.LogsOptions, h core.LogHandler) *LogsResult {
+	start := time.Now()
+	r := &LogsResult{}
+	defer func() {
+		r.Elapsed = time.Since(start)
+	}()
+
+	p, ok := s.config().Projects[project]
+	if !ok {
+		core.Error("Project not found", "project", project)
+
+		r.Errors = []error{ErrProjectNotFound}
+		return r
+	}
+
+	core.Debug("Streaming logs", "project", p, "environment", environment, "follow", opts.Follow)
+	r.Errors = p.Logs(environment, opts, h)
+	r.Done = len(r.Errors) == 0
+
+	return r
+}
+
+// parseLogsOptions reads the follow, tail, since, stdout and stderr options,
+// since can be a unix timestamp or a RFC3339 date.
+func parseLogsOptions(get func(key string) string) (core.LogsOptions, error) {
+	opts := core.LogsOptions{
+		Follow: get("follow") == "true" || get("follow") == "1",
+		Stdout: get("stdout") == "true" || get("stdout") == "1",
+		Stderr: get("stderr") == "true" || get("stderr") == "1",
+	}
+
+	if tail := get("tail"); tail != "" && tail != "all" {
+		if _, err := strconv.Atoi(tail); err != nil {
+			return opts, ErrInvalidTail
+		}
+
+		opts.Tail = tail
+	}
+
+	if since := get("since"); since != "" {
+		t, err := parseTime(since)
+		if err != nil {
+			return opts, ErrInvalidSince
+		}
+
+		opts.Since = t.Unix()
+	}
+
+	return opts, nil
+}
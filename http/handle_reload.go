@@ -0,0 +1,144 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/mcuadros/dockership/config"
+	"github.com/mcuadros/dockership/core"
+)
+
+type ReloadResult struct {
+	Done     bool
+	Elapsed  time.Duration
+	Projects []string `json:",omitempty"`
+	Errors   []string `json:",omitempty"`
+	Warnings []string `json:",omitempty"`
+}
+
+func (r *ReloadResult) errors() []error {
+	var errs []error
+	for _, err := range r.Errors {
+		errs = append(errs, errors.New(err))
+	}
+
+	return errs
+}
+
+// HandleReloadConfig reloads the config file, only allowed to the admins
+func (s *server) HandleReloadConfig(w http.ResponseWriter, r *http.Request) {
+	user := s.oauth.getUser(r)
+	if !s.isAdmin(user) {
+		s.forbiddenRequest(w, r, user)
+		return
+	}
+
+	result := s.ReloadConfig()
+	s.audit(r, user, ActionConfigReload, map[string]string{"file": configFile}, result.errors()...)
+
+	status := http.StatusOK
+	if !result.Done {
+		status = http.StatusBadRequest
+	}
+
+	s.json(w, status, result)
+}
+
+// systemUser is the actor of the actions not requested by any user, like the
+// config reloads triggered by SIGHUP or by the definitions at etcd
+var systemUser = &User{Login: "system"}
+
+// reloadConfigBySystem reloads the config and records it at the audit log as
+// done by the system, source being what triggered the reload
+func (s *server) reloadConfigBySystem(source string) *ReloadResult {
+	result := s.ReloadConfig()
+	s.audit(nil, systemUser, ActionConfigReload, map[string]string{
+		"file":   configFile,
+		"source": source,
+	}, result.errors()...)
+
+	return result
+}
+
+// ReloadConfig reads and validates the config file again, if it is valid the
+// current config is replaced and the connected clients get the new projects.
+// The running deploys keep using the projects of the previous config.
+func (s *server) ReloadConfig() *ReloadResult {
+	s.reload.Lock()
+	defer s.reload.Unlock()
+
+	start := time.Now()
+	r := &ReloadResult{}
+	defer func() {
+		r.Elapsed = time.Since(start)
+	}()
+
+	core.Info("Reloading config", "file", configFile)
+	c, problems, err := loadConfig(configFile)
+	for _, p := range problems.Warnings() {
+		r.Warnings = append(r.Warnings, p.Error())
+	}
+
+	if err != nil {
+		if errs := problems.Errors(); len(errs) != 0 {
+			for _, p := range errs {
+				r.Errors = append(r.Errors, p.Error())
+			}
+		} else {
+			r.Errors = []string{err.Error()}
+		}
+
+		core.Error("Config reload failed, keeping the current config", "file", configFile, "errors", len(r.Errors))
+		return r
+	}
+
+	previous := s.config()
+	keepTaskStatus(previous, c)
+	warnRestartRequired(previous, c)
+
+	s.stopWatchers()
+	s.cfg.Store(c)
+	s.startWatchers()
+	s.oauth.resetUsers()
+	s.EmitProjects(nil)
+
+	for name := range c.Projects {
+		r.Projects = append(r.Projects, name)
+	}
+
+	sort.Strings(r.Projects)
+	r.Done = true
+
+	core.Info(
+		"Config reloaded", "file", configFile, "projects", len(c.Projects),
+		"environments", len(c.Environments), "warnings", len(r.Warnings),
+	)
+
+	return r
+}
+
+// keepTaskStatus shares the task status of the projects still defined, so the
+// deploys started before the reload are seen by the reconciler
+func keepTaskStatus(previous, current *config.Config) {
+	for name, p := range current.Projects {
+		if old, ok := previous.Projects[name]; ok {
+			p.TaskStatus = old.TaskStatus
+		}
+	}
+}
+
+// warnRestartRequired logs a warning if any setting only read at start was
+// changed
+func warnRestartRequired(previous, current *config.Config) {
+	p, c := previous, current
+	if p.HTTP.Listen != c.HTTP.Listen ||
+		p.HTTP.GithubID != c.HTTP.GithubID ||
+		p.HTTP.GithubSecret != c.HTTP.GithubSecret ||
+		p.Global.Database != c.Global.Database ||
+		p.Global.EtcdPrefix != c.Global.EtcdPrefix ||
+		p.Audit != c.Audit {
+		core.Warning("The Listen, GithubID, GithubSecret, Database, EtcdPrefix and Audit settings require a restart")
+	}
+}
@@ -0,0 +1,79 @@
+package http
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/mcuadros/dockership/config"
+	"github.com/mcuadros/dockership/core"
+)
+
+type RollbackResult struct {
+	Done    bool
+	Elapsed time.Duration
+	Image   core.ImageID `json:",omitempty"`
+	Errors  []error      `json:",omitempty"`
+}
+
+// HandleRollback replaces the containers with the image of the revision query
+// parameter, by default the previous revision
+func (s *server) HandleRollback(w http.ResponseWriter, r *http.Request, project, environment string) {
+	user := s.oauth.getUser(r)
+	if !s.can(user, config.RoleDeployer, project, environment) {
+		s.forbiddenRequest(w, r, user)
+		return
+	}
+
+	revision := r.URL.Query().Get("revision")
+	result := s.DoRollback(user, project, environment, revision)
+
+	params := map[string]string{
+		"project":     project,
+		"environment": environment,
+		"revision":    revision,
+		"image":       string(result.Image),
+	}
+
+	s.audit(r, user, ActionRollback, params, result.Errors...)
+
+	status := http.StatusOK
+	if !result.Done {
+		status = http.StatusInternalServerError
+	}
+
+	s.json(w, status, result)
+}
+
+func (s *server) DoRollback(user *User, project, environment, revision string) *RollbackResult {
+	start := time.Now()
+	r := &RollbackResult{}
+	defer func() {
+		r.Elapsed = time.Since(start)
+	}()
+
+	p, ok := s.config().Projects[project]
+	if !ok {
+		core.Error("Project not found", "project", project)
+
+		r.Errors = []error{ErrProjectNotFound}
+		return r
+	}
+
+	core.Info(
+		"Starting rollback",
+		"project", p, "environment", environment, "revision", revision, "user", user,
+	)
+
+	r.Image, r.Errors = p.Rollback(environment, revision)
+	if len(r.Errors) != 0 {
+		for _, e := range r.Errors {
+			core.Critical(e.Error(), "project", p, "environment", environment)
+		}
+
+		return r
+	}
+
+	r.Done = true
+	core.Info("Rollback success", "project", p, "environment", environment, "image", r.Image)
+	return r
+}
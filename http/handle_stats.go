@@ -0,0 +1,149 @@
+package http
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mcuadros/dockership/config"
+	"github.com/mcuadros/dockership/core"
+
+	"gopkg.in/igm/sockjs-go.v2/sockjs"
+)
+
+const DefaultStatsInterval = 5 * time.Second
+
+type StatsResult struct {
+	Projects     map[string]map[string]*ProjectStats
+	Environments map[string]*core.ResourceStats
+	Errors       []error `json:",omitempty"`
+}
+
+type ProjectStats struct {
+	Containers []*core.ContainerStats
+	Total      *core.ResourceStats
+}
+
+// HandleStats subscribes the session to the stats of the running containers,
+// the stats are sent every interval seconds until the session is closed or
+// a new subscription is made, interval 0 cancels the subscription.
+func (s *server) HandleStats(msg Message, session sockjs.Session) {
+	project := msg.Request["project"]
+	user := s.oauth.getSessionUser(session)
+	if project != "" && !s.canView(user, project) {
+		s.forbidden(session, user, "stats")
+		return
+	}
+
+	interval := DefaultStatsInterval
+	if raw, ok := msg.Request["interval"]; ok {
+		seconds, err := strconv.Atoi(raw)
+		if err != nil || seconds < 0 {
+			core.Error("Invalid interval", "request", "stats", "interval", raw)
+			return
+		}
+
+		interval = time.Duration(seconds) * time.Second
+	}
+
+	stop := s.statsSubscriptions.Subscribe(session)
+	if interval == 0 {
+		s.statsSubscriptions.Unsubscribe(session, stop)
+		return
+	}
+
+	defer s.statsSubscriptions.Unsubscribe(session, stop)
+	for {
+		if err := s.sockjs.SendTo(session, "stats", s.GetStats(user, project), false); err != nil {
+			return
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+func (s *server) GetStats(user *User, project string) *StatsResult {
+	r := &StatsResult{
+		Projects:     make(map[string]map[string]*ProjectStats, 0),
+		Environments: make(map[string]*core.ResourceStats, 0),
+	}
+
+	var m sync.Mutex
+	var wg sync.WaitGroup
+	for name, p := range s.config().Projects {
+		if project != "" && project != name {
+			continue
+		}
+
+		if !s.canView(user, name) {
+			continue
+		}
+
+		r.Projects[name] = make(map[string]*ProjectStats, 0)
+		for _, e := range p.Environments {
+			if !s.can(user, config.RoleViewer, name, e.Name) {
+				continue
+			}
+
+			wg.Add(1)
+			go func(p *core.Project, e *core.Environment) {
+				defer wg.Done()
+				l, errs := p.StatsByEnvironment(e)
+				for _, err := range errs {
+					core.Warning(err.Error(), "project", p, "environment", e)
+				}
+
+				m.Lock()
+				defer m.Unlock()
+				r.Errors = append(r.Errors, errs...)
+				ps := &ProjectStats{Containers: l, Total: core.SumStats(l)}
+				r.Projects[p.Name][e.Name] = ps
+
+				if _, ok := r.Environments[e.Name]; !ok {
+					r.Environments[e.Name] = &core.ResourceStats{}
+				}
+
+				r.Environments[e.Name].Add(ps.Total)
+			}(p, e)
+		}
+	}
+
+	wg.Wait()
+	return r
+}
+
+type statsSubscriptions struct {
+	sessions map[string]chan bool
+	sync.Mutex
+}
+
+func newStatsSubscriptions() *statsSubscriptions {
+	return &statsSubscriptions{sessions: make(map[string]chan bool, 0)}
+}
+
+// Subscribe cancels any previous subscription of the session
+func (s *statsSubscriptions) Subscribe(session sockjs.Session) chan bool {
+	s.Lock()
+	defer s.Unlock()
+
+	if stop, ok := s.sessions[session.ID()]; ok {
+		close(stop)
+	}
+
+	stop := make(chan bool)
+	s.sessions[session.ID()] = stop
+	return stop
+}
+
+func (s *statsSubscriptions) Unsubscribe(session sockjs.Session, stop chan bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	if current, ok := s.sessions[session.ID()]; ok && current == stop {
+		delete(s.sessions, session.ID())
+	}
+}
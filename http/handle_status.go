@@ -2,12 +2,19 @@ package http
 
 import (
 	"fmt"
+	"sync"
+	"time"
 
+	"github.com/mcuadros/dockership/config"
 	"github.com/mcuadros/dockership/core"
 
 	"gopkg.in/igm/sockjs-go.v2/sockjs"
 )
 
+// StatusTimeout is the maximum time the status waits for the stats and the
+// drift of the containers, the ones not retrieved in time are left out
+var StatusTimeout = 3 * time.Second
+
 type StatusResult struct {
 	Project *core.Project
 	Status  map[string]*StatusRecord
@@ -22,17 +29,29 @@ type StatusRecord struct {
 func (s *server) HandleStatus(msg Message, session sockjs.Session) {
 	var project string
 	project, _ = msg.Request["project"]
-	s.sockjs.Send("status", s.GetStatus(project), false)
+
+	user := s.oauth.getSessionUser(session)
+	if project != "" && !s.canView(user, project) {
+		s.forbidden(session, user, "status")
+		return
+	}
+
+	s.sockjs.SendTo(session, "status", s.GetStatus(user, project), false)
 }
 
-func (s *server) GetStatus(project string) map[string]*StatusResult {
+func (s *server) GetStatus(user *User, project string) map[string]*StatusResult {
 	result := make(map[string]*StatusResult, 0)
 
-	for name, p := range s.config.Projects {
+	var details []*statusDetail
+	for name, p := range s.config().Projects {
 		if project != "" && project != name {
 			continue
 		}
 
+		if !s.canView(user, name) {
+			continue
+		}
+
 		record := &StatusResult{Project: p}
 		sl, errs := p.Status()
 		if len(errs) != 0 {
@@ -43,14 +62,99 @@ func (s *server) GetStatus(project string) map[string]*StatusResult {
 			record.Error = errs
 		} else {
 			record.Status = make(map[string]*StatusRecord, 0)
-			for _, s := range sl {
-				record.Status[s.Environment.Name] = &StatusRecord{s.LastRevision.Get(), s}
+			for _, ps := range sl {
+				if !s.can(user, config.RoleViewer, p.Name, ps.Environment.Name) {
+					continue
+				}
+
+				details = append(details, &statusDetail{project: p, status: ps})
+				record.Status[ps.Environment.Name] = &StatusRecord{ps.LastRevision.Get(), ps}
 			}
 		}
 
 		result[p.Name] = record
 	}
 
+	collectStatusDetails(details, StatusTimeout, getRunningStats, detectDrift)
 	fmt.Println("terminado", result)
 	return result
 }
+
+type statusDetail struct {
+	project *core.Project
+	status  *core.ProjectStatus
+}
+
+type statsFunc func(*core.ProjectStatus) ([]*core.ContainerStats, []error)
+
+type driftFunc func(*core.Project, *core.Environment) ([]*core.ContainerDrift, []error)
+
+func getRunningStats(ps *core.ProjectStatus) ([]*core.ContainerStats, []error) {
+	return ps.RunningStats()
+}
+
+func detectDrift(p *core.Project, e *core.Environment) ([]*core.ContainerDrift, []error) {
+	return p.DetectDrift(e)
+}
+
+// collectStatusDetails sets the stats and the drift of every status, they are
+// retrieved concurrently and the ones not finished before the timeout are
+// discarded, so a slow docker end point doesn't delay the whole status
+func collectStatusDetails(l []*statusDetail, timeout time.Duration, stats statsFunc, drift driftFunc) {
+	var m sync.Mutex
+	var wg sync.WaitGroup
+	var expired bool
+
+	run := func(d *statusDetail, get func() []error, set func()) {
+		defer wg.Done()
+		for _, err := range get() {
+			core.Warning(err.Error(), "project", d.project, "environment", d.status.Environment)
+		}
+
+		m.Lock()
+		defer m.Unlock()
+		if !expired {
+			set()
+		}
+	}
+
+	for _, d := range l {
+		d := d
+		var r []*core.ContainerStats
+		var drifts []*core.ContainerDrift
+
+		wg.Add(2)
+		go run(d, func() []error {
+			var errs []error
+			r, errs = stats(d.status)
+			return errs
+		}, func() {
+			d.status.Stats = r
+			d.status.TotalStats = core.SumStats(r)
+		})
+
+		go run(d, func() []error {
+			var errs []error
+			drifts, errs = drift(d.project, d.status.Environment)
+			return errs
+		}, func() {
+			d.status.Drift = drifts
+		})
+	}
+
+	done := make(chan bool)
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		core.Warning("Timeout retrieving the status details", "timeout", timeout)
+	}
+
+	m.Lock()
+	defer m.Unlock()
+	expired = true
+}
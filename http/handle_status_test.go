@@ -0,0 +1,46 @@
+package http
+
+import (
+	"time"
+
+	"github.com/mcuadros/dockership/core"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *HTTPSuite) TestCollectStatusDetails(c *C) {
+	p := &core.Project{Name: "foo"}
+	fast := &statusDetail{p, &core.ProjectStatus{Environment: &core.Environment{Name: "testing"}}}
+	slow := &statusDetail{p, &core.ProjectStatus{Environment: &core.Environment{Name: "live"}}}
+
+	hang := make(chan bool)
+	defer close(hang)
+
+	stats := func(ps *core.ProjectStatus) ([]*core.ContainerStats, []error) {
+		if ps == slow.status {
+			<-hang
+		}
+
+		return []*core.ContainerStats{{ResourceStats: core.ResourceStats{MemoryUsage: 42}}}, nil
+	}
+
+	drift := func(p *core.Project, e *core.Environment) ([]*core.ContainerDrift, []error) {
+		if e == slow.status.Environment {
+			<-hang
+		}
+
+		return []*core.ContainerDrift{{}}, nil
+	}
+
+	start := time.Now()
+	collectStatusDetails([]*statusDetail{fast, slow}, 100*time.Millisecond, stats, drift)
+	c.Assert(time.Since(start) < time.Second, Equals, true)
+
+	c.Assert(fast.status.Stats, HasLen, 1)
+	c.Assert(fast.status.TotalStats.MemoryUsage, Equals, uint64(42))
+	c.Assert(slow.status.Stats, HasLen, 0)
+	c.Assert(slow.status.TotalStats, IsNil)
+
+	c.Assert(fast.status.Drift, HasLen, 1)
+	c.Assert(slow.status.Drift, HasLen, 0)
+}
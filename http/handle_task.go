@@ -0,0 +1,132 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mcuadros/dockership/config"
+	"github.com/mcuadros/dockership/core"
+
+	"gopkg.in/igm/sockjs-go.v2/sockjs"
+)
+
+var ErrMissingCommand = errors.New("Missing command")
+
+type TaskResult struct {
+	Done     bool
+	ExitCode int
+	Elapsed  time.Duration
+	Output   string  `json:",omitempty"`
+	Errors   []error `json:",omitempty"`
+}
+
+func (s *server) HandleTask(msg Message, session sockjs.Session) {
+	project, ok := msg.Request["project"]
+	if !ok {
+		core.Error("Missing project", "request", "task")
+		return
+	}
+
+	environment, ok := msg.Request["environment"]
+	if !ok {
+		core.Error("Missing environment", "request", "task")
+		return
+	}
+
+	command := msg.Request["command"]
+	endPoint := msg.Request["endpoint"]
+	user := s.oauth.getSessionUser(session)
+	if !s.can(user, config.RoleDeployer, project, environment) {
+		s.forbidden(session, user, "task")
+		return
+	}
+
+	now := time.Now()
+	writer := NewSockJSWriter(s.sockjs, "task")
+	writer.SetFilter(s.viewFilter(project))
+	writer.SetFormater(func(raw []byte) []byte {
+		str, _ := json.Marshal(map[string]string{
+			"environment": environment,
+			"project":     project,
+			"command":     command,
+			"date":        now.String(),
+			"log":         string(raw),
+		})
+
+		return str
+	})
+
+	result := s.DoTask(writer, user, project, environment, command, endPoint)
+	s.auditTask(session.Request(), user, project, environment, command, endPoint, result)
+	s.sockjs.SendFiltered("task-result", map[string]interface{}{
+		"project":     project,
+		"environment": environment,
+		"command":     command,
+		"date":        now.String(),
+		"result":      result,
+	}, false, s.viewFilter(project))
+}
+
+func (s *server) auditTask(
+	r *http.Request, user *User, project, environment, command, endPoint string, result *TaskResult,
+) {
+	errs := result.Errors
+	if len(errs) == 0 && !result.Done {
+		errs = []error{fmt.Errorf("Exit code %d", result.ExitCode)}
+	}
+
+	s.audit(r, user, ActionTask, map[string]string{
+		"project":     project,
+		"environment": environment,
+		"command":     command,
+		"endpoint":    endPoint,
+		"exit-code":   strconv.Itoa(result.ExitCode),
+	}, errs...)
+}
+
+func (s *server) DoTask(w io.Writer, user *User, project, environment, command, endPoint string) *TaskResult {
+	start := time.Now()
+	r := &TaskResult{ExitCode: -1}
+	defer func() {
+		r.Elapsed = time.Since(start)
+	}()
+
+	if command == "" {
+		r.Errors = []error{ErrMissingCommand}
+		return r
+	}
+
+	p, ok := s.config().Projects[project]
+	if !ok {
+		core.Error("Project not found", "project", project)
+
+		r.Errors = []error{ErrProjectNotFound}
+		return r
+	}
+
+	core.Info(
+		"Starting task",
+		"project", p, "environment", environment, "command", command, "user", user,
+	)
+
+	code, err := p.RunTask(environment, command, endPoint, w)
+	r.ExitCode = code
+	if err != nil {
+		r.Errors = []error{err}
+		core.Error(err.Error(), "project", p, "environment", environment, "command", command, "user", user)
+		return r
+	}
+
+	r.Done = code == 0
+	core.Info(
+		"Task finished",
+		"project", p, "environment", environment, "command", command, "user", user, "exit-code", code,
+	)
+
+	return r
+}
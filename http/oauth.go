@@ -8,10 +8,13 @@ import (
 	"sync"
 
 	"github.com/mcuadros/dockership/config"
+	"github.com/mcuadros/dockership/core"
+	"github.com/mcuadros/dockership/store"
 
 	"github.com/google/go-github/github"
 	"github.com/gorilla/sessions"
 	"golang.org/x/oauth2"
+	"gopkg.in/igm/sockjs-go.v2/sockjs"
 )
 
 const (
@@ -20,8 +23,23 @@ const (
 )
 
 type User struct {
+	Login    string
 	Fullname string
 	Avatar   string
+	Teams    []string
+	token    *store.Token
+}
+
+func (u *User) String() string {
+	if u == nil {
+		return "anonymous"
+	}
+
+	if u.token != nil {
+		return fmt.Sprintf("%s (token %q)", u.Login, u.token.Name)
+	}
+
+	return u.Login
 }
 
 type OAuth struct {
@@ -30,13 +48,16 @@ type OAuth struct {
 	PathCallback string // Path to handle callback from OAuth 2.0 backend
 	PathError    string // Path to handle error cases.
 	OAuthConfig  *oauth2.Config
-	Config       *config.Config
+	Config       func() *config.Config
 	users        map[string]*User
+	sessions     map[string]*User
 	store        sessions.Store
 	sync.Mutex
 }
 
-func NewOAuth(config *config.Config) *OAuth {
+// NewOAuth returns an OAuth handler, the HTTP settings are read from the
+// config returned by the given function
+func NewOAuth(config func() *config.Config) *OAuth {
 	authURL := "https://github.com/login/oauth/authorize"
 	tokenURL := "https://github.com/login/oauth/access_token"
 
@@ -46,17 +67,18 @@ func NewOAuth(config *config.Config) *OAuth {
 		PathCallback: "/oauth2callback",
 		PathError:    "/oauth2error",
 		OAuthConfig: &oauth2.Config{
-			ClientID:     config.HTTP.GithubID,
-			ClientSecret: config.HTTP.GithubSecret,
+			ClientID:     config().HTTP.GithubID,
+			ClientSecret: config().HTTP.GithubSecret,
 			Scopes:       []string{"read:org"},
 			Endpoint: oauth2.Endpoint{
 				AuthURL:  authURL,
 				TokenURL: tokenURL,
 			},
 		},
-		Config: config,
-		users:  make(map[string]*User, 0),
-		store:  sessions.NewCookieStore([]byte("cookie-key")),
+		Config:   config,
+		users:    make(map[string]*User, 0),
+		sessions: make(map[string]*User, 0),
+		store:    sessions.NewCookieStore([]byte("cookie-key")),
 	}
 }
 
@@ -153,6 +175,24 @@ func (s *OAuth) getToken(r *http.Request) *oauth2.Token {
 	return &tok
 }
 
+func (o *OAuth) getUser(r *http.Request) *User {
+	if user, ok := r.Context().Value(userKey{}).(*User); ok {
+		return user
+	}
+
+	token := o.getToken(r)
+	if token == nil {
+		return nil
+	}
+
+	user, err := o.getValidUser(token)
+	if err != nil {
+		return nil
+	}
+
+	return user
+}
+
 func (o *OAuth) getValidUser(token *oauth2.Token) (*User, error) {
 	o.Lock()
 	user, ok := o.users[token.AccessToken]
@@ -177,6 +217,10 @@ func (o *OAuth) getValidUser(token *oauth2.Token) (*User, error) {
 	}
 
 	user = &User{}
+	if guser != nil && guser.Login != nil {
+		user.Login = *guser.Login
+	}
+
 	if guser != nil && guser.Name != nil {
 		user.Fullname = *guser.Name
 	} else if guser.Login != nil {
@@ -187,6 +231,11 @@ func (o *OAuth) getValidUser(token *oauth2.Token) (*User, error) {
 		user.Avatar = *guser.AvatarURL
 	}
 
+	user.Teams, err = o.getUserTeams(c)
+	if err != nil {
+		core.Warning("Unable to retrieve user teams", "user", user, "error", err)
+	}
+
 	o.Lock()
 	o.users[token.AccessToken] = user
 	o.Unlock()
@@ -194,6 +243,70 @@ func (o *OAuth) getValidUser(token *oauth2.Token) (*User, error) {
 	return user, nil
 }
 
+// resetUsers forgets the validated users, so they are validated again against
+// the current config
+func (o *OAuth) resetUsers() {
+	o.Lock()
+	defer o.Unlock()
+
+	o.users = make(map[string]*User, 0)
+	o.sessions = make(map[string]*User, 0)
+}
+
+// getSessionUser returns the user of the SockJS session, it is resolved once
+// by session, even if invalid, since the filters run it for every message
+func (o *OAuth) getSessionUser(session sockjs.Session) *User {
+	o.Lock()
+	user, ok := o.sessions[session.ID()]
+	o.Unlock()
+
+	if ok {
+		return user
+	}
+
+	user = o.getUser(session.Request())
+
+	o.Lock()
+	o.sessions[session.ID()] = user
+	o.Unlock()
+
+	return user
+}
+
+// forgetSession removes the user of the closed session
+func (o *OAuth) forgetSession(session sockjs.Session) {
+	o.Lock()
+	defer o.Unlock()
+
+	delete(o.sessions, session.ID())
+}
+
+// getUserTeams returns the Github teams of the user as org/team-slug
+func (o *OAuth) getUserTeams(c *github.Client) ([]string, error) {
+	var r []string
+	opt := &github.ListOptions{PerPage: 100}
+	for {
+		teams, resp, err := c.Organizations.ListUserTeams(opt)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, t := range teams {
+			if t.Slug == nil || t.Organization == nil || t.Organization.Login == nil {
+				continue
+			}
+
+			r = append(r, *t.Organization.Login+"/"+*t.Slug)
+		}
+
+		if resp == nil || resp.NextPage == 0 {
+			return r, nil
+		}
+
+		opt.Page = resp.NextPage
+	}
+}
+
 func (o *OAuth) isValidUser(c *github.Client, u *github.User) error {
 	if err := o.validateGithubOrganization(c, u); err != nil {
 		return err
@@ -207,7 +320,7 @@ func (o *OAuth) isValidUser(c *github.Client, u *github.User) error {
 }
 
 func (o *OAuth) validateGithubOrganization(c *github.Client, u *github.User) error {
-	org := o.Config.HTTP.GithubOrganization
+	org := o.Config().HTTP.GithubOrganization
 	if org == "" {
 		return nil
 	}
@@ -225,11 +338,11 @@ func (o *OAuth) validateGithubOrganization(c *github.Client, u *github.User) err
 }
 
 func (o *OAuth) validateGithubUser(c *github.Client, u *github.User) error {
-	if len(o.Config.HTTP.GithubUsers) == 0 {
+	if len(o.Config().HTTP.GithubUsers) == 0 {
 		return nil
 	}
 
-	for _, user := range o.Config.HTTP.GithubUsers {
+	for _, user := range o.Config().HTTP.GithubUsers {
 		if user == *u.Login {
 			return nil
 		}
@@ -0,0 +1,45 @@
+package http
+
+import (
+	"context"
+	"net/http"
+
+	. "gopkg.in/check.v1"
+)
+
+type fakeSession struct {
+	id      string
+	request *http.Request
+	sent    []string
+}
+
+func (s *fakeSession) ID() string                          { return s.id }
+func (s *fakeSession) Request() *http.Request              { return s.request }
+func (s *fakeSession) Recv() (string, error)               { return "", nil }
+func (s *fakeSession) Close(status uint32, r string) error { return nil }
+func (s *fakeSession) Send(msg string) error               { s.sent = append(s.sent, msg); return nil }
+
+func newSession(id string, user *User) *fakeSession {
+	r, _ := http.NewRequest("GET", "/sockjs", nil)
+	if user != nil {
+		r = r.WithContext(context.WithValue(r.Context(), userKey{}, user))
+	}
+
+	return &fakeSession{id: id, request: r}
+}
+
+func (s *HTTPSuite) TestOAuth_getSessionUser(c *C) {
+	o := &OAuth{users: make(map[string]*User, 0), sessions: make(map[string]*User, 0)}
+
+	session := newSession("foo", &User{Login: "foo"})
+	c.Assert(o.getSessionUser(session).Login, Equals, "foo")
+
+	session.request = session.request.WithContext(context.WithValue(session.request.Context(), userKey{}, &User{Login: "bar"}))
+	c.Assert(o.getSessionUser(session).Login, Equals, "foo")
+
+	o.forgetSession(session)
+	c.Assert(o.getSessionUser(session).Login, Equals, "bar")
+
+	o.resetUsers()
+	c.Assert(o.sessions, HasLen, 0)
+}
@@ -1,14 +1,24 @@
 package http
 
 import (
+	"bytes"
 	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
 
 	"github.com/mcuadros/dockership/config"
 	"github.com/mcuadros/dockership/core"
+	"github.com/mcuadros/dockership/store"
 
 	"github.com/gorilla/mux"
 	"gopkg.in/igm/sockjs-go.v2/sockjs"
@@ -23,6 +33,8 @@ func Start(version, build string) {
 
 	s := &server{serverID: fmt.Sprintf("dockership %s, build %s", version, build)}
 	s.readConfig(configFile)
+	s.openStore()
+	s.configureAudit()
 	s.configure()
 	s.configStaticAssets()
 	s.configureAuth()
@@ -34,46 +46,109 @@ type server struct {
 	sockjs   *SockJS
 	mux      *mux.Router
 	oauth    *OAuth
-	config   config.Config
+	cfg      atomic.Value
+	reload   sync.Mutex
+
+	statsSubscriptions *statsSubscriptions
+	watcher            *core.Watcher
+	reconciler         *core.Reconciler
+	store              *store.Store
+	auditor            *auditor
 }
 
 func (s *server) configure() {
 	s.sockjs = NewSockJS()
 	s.mux = mux.NewRouter()
+	s.statsSubscriptions = newStatsSubscriptions()
 
 	s.sockjs.AddHandler("connect", s.HandleConnect)
 	s.sockjs.AddHandler("containers", s.HandleContainers)
 	s.sockjs.AddHandler("status", s.HandleStatus)
 	s.sockjs.AddHandler("deploy", s.HandleDeploy)
+	s.sockjs.AddHandler("deploy-environment", s.HandleEnvironmentDeploy)
+	s.sockjs.AddHandler("task", s.HandleTask)
+	s.sockjs.AddHandler("containers/logs", s.HandleContainersLogs)
+	s.sockjs.AddHandler("stats", s.HandleStats)
 
 	// socket
 	s.mux.Path("/socket/{any:.*}").Handler(sockjs.NewHandler("/socket", sockjs.DefaultOptions, func(session sockjs.Session) {
 		s.sockjs.AddSessionAndRead(session)
 	}))
 
+	// exec
+	s.mux.Path("/exec/{any:.*}").Handler(sockjs.NewHandler("/exec", sockjs.DefaultOptions, s.HandleExecSession))
+
 	// logged-user
 	s.mux.Path("/rest/user").Methods("GET").HandlerFunc(
 		func(w http.ResponseWriter, r *http.Request) {
-			user, _ := s.oauth.getValidUser(s.oauth.getToken(r))
-			s.json(w, 200, user)
+			user := s.oauth.getUser(r)
+			s.json(w, 200, &UserResult{user, s.getPermissions(user)})
 		},
 	)
 
 	s.mux.Path("/rest/projects").Methods("GET").HandlerFunc(
 		func(w http.ResponseWriter, r *http.Request) {
-			s.json(w, 200, s.config.Projects)
+			s.json(w, 200, s.getVisibleProjects(s.oauth.getUser(r)))
 		},
 	)
 
 	s.mux.Path("/rest/status").Methods("GET").HandlerFunc(
 		func(w http.ResponseWriter, r *http.Request) {
-			s.json(w, 200, s.GetStatus(""))
+			s.json(w, 200, s.GetStatus(s.oauth.getUser(r), ""))
+		},
+	)
+
+	s.mux.Path("/rest/containers/{project}").Methods("GET").HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			user, project := s.oauth.getUser(r), mux.Vars(r)["project"]
+			if !s.canView(user, project) {
+				s.forbiddenRequest(w, r, user)
+				return
+			}
+
+			s.json(w, 200, s.GetContainers(user, project))
+		},
+	)
+
+	s.mux.Path("/rest/drift").Methods("GET").HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			s.json(w, 200, s.GetDrift(s.oauth.getUser(r), ""))
+		},
+	)
+
+	s.mux.Path("/rest/drift/{project}").Methods("GET").HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			user, project := s.oauth.getUser(r), mux.Vars(r)["project"]
+			if !s.canView(user, project) {
+				s.forbiddenRequest(w, r, user)
+				return
+			}
+
+			s.json(w, 200, s.GetDrift(user, project))
+		},
+	)
+
+	s.mux.Path("/rest/stats").Methods("GET").HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			user, project := s.oauth.getUser(r), r.URL.Query().Get("project")
+			if project != "" && !s.canView(user, project) {
+				s.forbiddenRequest(w, r, user)
+				return
+			}
+
+			s.json(w, 200, s.GetStats(user, project))
 		},
 	)
 
 	s.mux.Path("/rest/status/{project}").Methods("GET").HandlerFunc(
 		func(w http.ResponseWriter, r *http.Request) {
-			s.json(w, 200, s.GetStatus(mux.Vars(r)["project"]))
+			user, project := s.oauth.getUser(r), mux.Vars(r)["project"]
+			if !s.canView(user, project) {
+				s.forbiddenRequest(w, r, user)
+				return
+			}
+
+			s.json(w, 200, s.GetStatus(user, project))
 		},
 	)
 
@@ -81,12 +156,131 @@ func (s *server) configure() {
 		func(w http.ResponseWriter, r *http.Request) {
 			vars := mux.Vars(r)
 
+			user := s.oauth.getUser(r)
+			if !s.can(user, config.RoleDeployer, vars["project"], vars["environment"]) {
+				s.forbiddenRequest(w, r, user)
+				return
+			}
+
+			if format := getStreamFormat(r); format != "" {
+				stream := newDeployStream(w, format, vars["project"], vars["environment"])
+				result := s.DoDeploy(stream, user, vars["project"], vars["environment"], true)
+				s.auditDeploy(r, user, vars["project"], vars["environment"], result)
+				stream.Close(result)
+				return
+			}
+
+			status := 200
+			result := s.DoDeploy(ioutil.Discard, user, vars["project"], vars["environment"], true)
+			s.auditDeploy(r, user, vars["project"], vars["environment"], result)
+			if !result.Done {
+				status = 500
+			}
+
+			s.json(w, status, result)
+		},
+	)
+
+	s.mux.Path("/rest/deploy/{environment}").Methods("GET").HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			vars := mux.Vars(r)
+
+			var projects []string
+			if list := r.URL.Query().Get("projects"); list != "" {
+				projects = strings.Split(list, ",")
+			}
+
+			user := s.oauth.getUser(r)
+			if !s.canDeployAll(user, projects, vars["environment"]) {
+				s.forbiddenRequest(w, r, user)
+				return
+			}
+
+			status := 200
+			result := s.DoEnvironmentDeploy(func(string) io.Writer {
+				return ioutil.Discard
+			}, user, projects, vars["environment"], true)
+
+			s.auditEnvironmentDeploy(r, user, projects, vars["environment"], result)
+
+			if !result.Done {
+				status = 500
+			}
+
+			s.json(w, status, result)
+		},
+	)
+
+	s.mux.Path("/rest/rollback/{project}/{environment}").Methods("GET").HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			vars := mux.Vars(r)
+			s.HandleRollback(w, r, vars["project"], vars["environment"])
+		},
+	)
+
+	s.mux.Path("/rest/logs/{project}/{environment}").Methods("GET").HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			vars := mux.Vars(r)
+			s.HandleLogsRequest(w, r, vars["project"], vars["environment"])
+		},
+	)
+
+	s.mux.Path("/rest/audit").Methods("GET").HandlerFunc(s.HandleAudit)
+
+	s.mux.Path("/rest/config/reload").Methods("POST").HandlerFunc(s.HandleReloadConfig)
+
+	s.mux.Path("/rest/definitions/{kind}/{name}").Methods("PUT").HandlerFunc(s.HandlePutDefinition)
+	s.mux.Path("/rest/definitions/{kind}/{name}").Methods("DELETE").HandlerFunc(s.HandleDeleteDefinition)
+
+	s.mux.Path("/rest/history").Methods("GET").HandlerFunc(s.HandleHistory)
+
+	s.mux.Path("/rest/tokens").Methods("GET").HandlerFunc(s.HandleTokens)
+	s.mux.Path("/rest/tokens").Methods("POST").HandlerFunc(s.HandleCreateToken)
+	s.mux.Path("/rest/tokens/{id}").Methods("DELETE").HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			s.HandleRevokeToken(w, r, mux.Vars(r)["id"])
+		},
+	)
+
+	s.mux.Path("/rest/history/{id}").Methods("GET").HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			s.HandleHistoryDeploy(w, r, mux.Vars(r)["id"])
+		},
+	)
+
+	s.mux.Path("/rest/history/{id}/log").Methods("GET").HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			s.HandleHistoryLog(w, r, mux.Vars(r)["id"])
+		},
+	)
+
+	s.mux.Path("/rest/task/{project}/{environment}").Methods("POST").HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			vars := mux.Vars(r)
+			output := bytes.NewBuffer(nil)
+
+			user := s.oauth.getUser(r)
+			if !s.can(user, config.RoleDeployer, vars["project"], vars["environment"]) {
+				s.forbiddenRequest(w, r, user)
+				return
+			}
+
+			result := s.DoTask(
+				output, user, vars["project"], vars["environment"],
+				r.FormValue("command"), r.FormValue("endpoint"),
+			)
+
+			s.auditTask(
+				r, user, vars["project"], vars["environment"],
+				r.FormValue("command"), r.FormValue("endpoint"), result,
+			)
+
 			status := 200
-			result := s.DoDeploy(ioutil.Discard, vars["project"], vars["environment"], true)
 			if !result.Done {
 				status = 500
 			}
 
+			result.Output = output.String()
 			s.json(w, status, result)
 		},
 	)
@@ -135,36 +329,143 @@ func (s *server) configStaticAssets() {
 
 }
 func (s *server) configureAuth() {
-	s.oauth = NewOAuth(&s.config)
+	s.oauth = NewOAuth(s.config)
+	s.sockjs.OnClose(s.oauth.forgetSession)
+}
+
+// config returns the current config, it is replaced on every reload so it
+// should be retrieved once by operation
+func (s *server) config() *config.Config {
+	return s.cfg.Load().(*config.Config)
 }
 
 func (s *server) readConfig(configFile string) {
-	if err := s.config.LoadFile(configFile); err != nil {
+	c, _, err := loadConfig(configFile)
+	if err != nil {
 		panic(err)
 	}
+
+	s.cfg.Store(c)
+}
+
+// loadConfig reads and validates the config file, the problems found are
+// logged and returned
+func loadConfig(filename string) (*config.Config, config.Problems, error) {
+	c := &config.Config{}
+	if err := c.LoadFile(filename); err != nil {
+		return nil, nil, err
+	}
+
+	problems := c.Validate()
+	for _, p := range problems.Warnings() {
+		core.Warning("Config problem", "problem", p)
+	}
+
+	errs := problems.Errors()
+	for _, p := range errs {
+		core.Error("Config problem", "problem", p)
+	}
+
+	if len(errs) != 0 {
+		return nil, problems, fmt.Errorf("Invalid config file %q:\n%s", filename, errs)
+	}
+
+	return c, problems, nil
 }
 
 func (s *server) run() {
 	writer := NewSockJSWriter(s.sockjs, "log")
+	writer.SetFilter(s.adminFilter)
 	subs := subscribeWriteToEvents(writer)
 	defer unsubscribeEvents(subs)
 
-	core.Info("HTTP server running", "host:port", s.config.HTTP.Listen)
-	if err := http.ListenAndServe(s.config.HTTP.Listen, s); err != nil {
+	sub := &core.Subscriber{s.EmitContainerEvent}
+	core.Events.Subscribe(core.EventContainer, sub)
+	defer core.Events.Unsubscribe(core.EventContainer, sub)
+
+	states := &core.Subscriber{s.recordDesiredState}
+	core.Events.Subscribe(core.EventDesiredState, states)
+	defer core.Events.Unsubscribe(core.EventDesiredState, states)
+
+	s.startWatchers()
+	defer s.stopWatchers()
+
+	if s.config().Global.EtcdPrefix != "" {
+		stop := make(chan bool)
+		defer close(stop)
+
+		err := s.config().WatchDefinitions(stop, func() {
+			core.Info("Definitions changed at etcd", "prefix", s.config().Global.EtcdPrefix)
+			s.reloadConfigBySystem("etcd")
+		})
+
+		if err != nil {
+			core.Error("Unable to watch the definitions at etcd", "error", err)
+		}
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			s.reloadConfigBySystem("sighup")
+		}
+	}()
+
+	core.Info("HTTP server running", "host:port", s.config().HTTP.Listen)
+	if err := http.ListenAndServe(s.config().HTTP.Listen, s); err != nil {
 		panic(err)
 	}
 }
 
+// startWatchers starts the docker events watcher and, if enabled, the
+// reconciler of the projects at the current config
+func (s *server) startWatchers() {
+	c := s.config()
+	s.watcher = core.NewWatcher(c.Projects)
+	s.watcher.Start()
+
+	s.reconciler = nil
+	if c.Global.Reconcile {
+		interval := time.Duration(c.Global.ReconcileInterval) * time.Second
+		s.reconciler = core.NewReconciler(c.Projects, interval)
+		s.reconciler.Start()
+	}
+}
+
+func (s *server) stopWatchers() {
+	s.watcher.Stop()
+	if s.reconciler != nil {
+		s.reconciler.Stop()
+	}
+}
+
 func (s *server) json(w http.ResponseWriter, code int, response interface{}) {
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
 	encoder := json.NewEncoder(w)
 	encoder.Encode(response)
 }
 
+func (s *server) jsonError(w http.ResponseWriter, code int, err error) {
+	s.json(w, code, map[string]string{"Error": err.Error()})
+}
+
 func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	if s.oauth.Handler(w, r) {
-		core.Debug("Handling request", "url", r.URL)
-		w.Header().Set("Server", s.serverID)
-		s.mux.ServeHTTP(w, r)
+	if secret := getBearerToken(r); secret != "" {
+		req, err := s.authenticateToken(r, secret)
+		if err != nil {
+			core.Warning("Invalid API token", "ip", getRemoteIP(r, s.config().HTTP.TrustedProxies), "error", err)
+			s.jsonError(w, http.StatusUnauthorized, ErrInvalidToken)
+			return
+		}
+
+		r = req
+	} else if !s.oauth.Handler(w, r) {
+		return
 	}
+
+	core.Debug("Handling request", "url", r.URL, "user", s.oauth.getUser(r))
+	w.Header().Set("Server", s.serverID)
+	s.mux.ServeHTTP(w, r)
 }
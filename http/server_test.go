@@ -0,0 +1,23 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *HTTPSuite) TestServer_json(c *C) {
+	srv := &server{}
+
+	w := httptest.NewRecorder()
+	srv.json(w, http.StatusInternalServerError, &DeployResult{})
+	c.Assert(w.Code, Equals, http.StatusInternalServerError)
+	c.Assert(w.Header().Get("Content-Type"), Equals, "application/json; charset=utf-8")
+
+	w = httptest.NewRecorder()
+	srv.jsonError(w, http.StatusNotFound, errors.New("foo"))
+	c.Assert(w.Code, Equals, http.StatusNotFound)
+	c.Assert(w.Body.String(), Equals, "{\"Error\":\"foo\"}\n")
+}
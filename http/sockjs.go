@@ -1,6 +1,7 @@
 package http
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"sync"
@@ -17,20 +18,67 @@ type Message struct {
 
 type SockJSHandler func(msg Message, session sockjs.Session)
 
+type SockJSFilter func(session sockjs.Session) bool
+
 type SockJS struct {
 	sessions []sockjs.Session
+	contexts map[string]context.Context
 	handlers map[string]SockJSHandler
+	onClose  []func(session sockjs.Session)
 	sync.Mutex
 }
 
 func NewSockJS() *SockJS {
 	return &SockJS{
 		sessions: make([]sockjs.Session, 0),
+		contexts: make(map[string]context.Context, 0),
 		handlers: make(map[string]SockJSHandler, 0),
 	}
 }
 
 func (s *SockJS) Send(event, data interface{}, isJSON bool) {
+	s.SendFiltered(event, data, isJSON, nil)
+}
+
+// SendFiltered sends the event to the sessions accepted by the filter, a nil
+// filter accepts all the sessions
+func (s *SockJS) SendFiltered(event, data interface{}, isJSON bool, filter SockJSFilter) {
+	raw, err := s.format(event, data, isJSON)
+	if err != nil {
+		core.Error(fmt.Sprintf("Error SockJS send: %q", err.Error()))
+		return
+	}
+
+	for _, session := range s.sessions {
+		if filter == nil || filter(session) {
+			session.Send(raw)
+		}
+	}
+}
+
+// SendEach sends the event to every session, with the data returned by the
+// given function for each session, sessions with nil data are skipped
+func (s *SockJS) SendEach(event interface{}, data func(session sockjs.Session) interface{}) {
+	for _, session := range s.sessions {
+		if d := data(session); d != nil {
+			if err := s.SendTo(session, event, d, false); err != nil {
+				core.Error(fmt.Sprintf("Error SockJS send: %q", err.Error()))
+			}
+		}
+	}
+}
+
+// SendTo sends the event only to the given session
+func (s *SockJS) SendTo(session sockjs.Session, event, data interface{}, isJSON bool) error {
+	raw, err := s.format(event, data, isJSON)
+	if err != nil {
+		return err
+	}
+
+	return session.Send(raw)
+}
+
+func (s *SockJS) format(event, data interface{}, isJSON bool) (string, error) {
 	var result []byte
 	if isJSON {
 		result = data.([]byte)
@@ -38,27 +86,54 @@ func (s *SockJS) Send(event, data interface{}, isJSON bool) {
 		var err error
 		result, err = json.Marshal(data)
 		if err != nil {
-			core.Error(fmt.Sprintf("Error SockJS send: %q", err.Error()))
-			return
+			return "", err
 		}
 	}
 
-	raw := fmt.Sprintf("{\"event\":\"%s\", \"result\":%s}", event, result)
-
-	for _, session := range s.sessions {
-		session.Send(raw)
-	}
+	return fmt.Sprintf("{\"event\":\"%s\", \"result\":%s}", event, result), nil
 }
 
 func (s *SockJS) AddSessionAndRead(session sockjs.Session) {
+	ctx, cancel := context.WithCancel(context.Background())
 	s.Lock()
 	s.sessions = append(s.sessions, session)
+	s.contexts[session.ID()] = ctx
 	s.Unlock()
 
+	defer func() {
+		cancel()
+		s.Lock()
+		delete(s.contexts, session.ID())
+		s.Unlock()
+
+		for _, fn := range s.onClose {
+			fn(session)
+		}
+	}()
+
 	s.onConnect(session)
 	s.Read(session)
 }
 
+// OnClose registers a function called when a session is closed
+func (s *SockJS) OnClose(fn func(session sockjs.Session)) {
+	s.onClose = append(s.onClose, fn)
+}
+
+// Context returns a context canceled when the session is closed
+func (s *SockJS) Context(session sockjs.Session) context.Context {
+	s.Lock()
+	defer s.Unlock()
+
+	if ctx, ok := s.contexts[session.ID()]; ok {
+		return ctx
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	return ctx
+}
+
 func (s *SockJS) onConnect(session sockjs.Session) {
 	s.handleMessage(Message{Event: "connect"}, session)
 }
@@ -96,6 +171,7 @@ type SockJSWriter struct {
 	event     string
 	sockjs    *SockJS
 	formatter SockJSWriterFormatter
+	filter    SockJSFilter
 }
 
 type SockJSWriterFormatter func(raw []byte) []byte
@@ -114,8 +190,13 @@ func (s *SockJSWriter) SetFormater(f SockJSWriterFormatter) {
 	s.formatter = f
 }
 
+// SetFilter restricts the sessions receiving the output
+func (s *SockJSWriter) SetFilter(f SockJSFilter) {
+	s.filter = f
+}
+
 func (s *SockJSWriter) Write(raw []byte) (int, error) {
-	s.sockjs.Send(s.event, s.formatter(raw), true)
+	s.sockjs.SendFiltered(s.event, s.formatter(raw), true, s.filter)
 
 	return len(raw), nil
 }
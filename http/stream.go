@@ -0,0 +1,176 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	StreamSSE    = "sse"
+	StreamNDJSON = "ndjson"
+)
+
+// DeployLine is a line of the deploy output, as is sent to the SockJS clients
+// plus the docker end point producing it
+type DeployLine struct {
+	Project     string `json:"project"`
+	Environment string `json:"environment"`
+	EndPoint    string `json:"endpoint"`
+	Date        string `json:"date"`
+	Log         string `json:"log"`
+}
+
+// getStreamFormat returns the format requested with the stream query parameter
+// or the Accept header, empty if the response should not be streamed
+func getStreamFormat(r *http.Request) string {
+	switch format := r.URL.Query().Get("stream"); format {
+	case StreamSSE, StreamNDJSON:
+		return format
+	}
+
+	accept := r.Header.Get("Accept")
+	switch {
+	case strings.Contains(accept, "text/event-stream"):
+		return StreamSSE
+	case strings.Contains(accept, "application/x-ndjson"):
+		return StreamNDJSON
+	}
+
+	return ""
+}
+
+// deployStream writes the deploy output line by line as Server-Sent Events or
+// JSON lines, the last event is the DeployResult
+type deployStream struct {
+	format  string
+	w       io.Writer
+	flusher http.Flusher
+	line    DeployLine
+	writers []*deployStreamWriter
+	sync.Mutex
+}
+
+func newDeployStream(w http.ResponseWriter, format, project, environment string) *deployStream {
+	if format == StreamSSE {
+		w.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-cache")
+	} else {
+		w.Header().Set("Content-Type", "application/x-ndjson; charset=utf-8")
+	}
+
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+
+	return &deployStream{
+		format:  format,
+		w:       w,
+		flusher: flusher,
+		line: DeployLine{
+			Project:     project,
+			Environment: environment,
+			Date:        time.Now().String(),
+		},
+	}
+}
+
+// Write writes the output not related to any docker end point
+func (s *deployStream) Write(p []byte) (int, error) {
+	return s.ForEndPoint("").Write(p)
+}
+
+func (s *deployStream) ForEndPoint(endPoint string) io.Writer {
+	s.Lock()
+	defer s.Unlock()
+
+	for _, w := range s.writers {
+		if w.endPoint == endPoint {
+			return w
+		}
+	}
+
+	w := &deployStreamWriter{stream: s, endPoint: endPoint}
+	s.writers = append(s.writers, w)
+	return w
+}
+
+// Close writes the pending output and the result as the last event
+func (s *deployStream) Close(result *DeployResult) {
+	s.Lock()
+	writers := s.writers
+	s.Unlock()
+
+	for _, w := range writers {
+		w.Flush()
+	}
+
+	s.send("deploy-result", result)
+}
+
+func (s *deployStream) sendLine(endPoint, log string) {
+	line := s.line
+	line.EndPoint = endPoint
+	line.Log = log
+
+	s.send("deploy", &line)
+}
+
+func (s *deployStream) send(event string, data interface{}) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	if s.format == StreamSSE {
+		fmt.Fprintf(s.w, "event: %s\ndata: %s\n\n", event, raw)
+	} else {
+		s.w.Write(append(raw, '\n'))
+	}
+
+	if s.flusher != nil {
+		s.flusher.Flush()
+	}
+}
+
+type deployStreamWriter struct {
+	stream   *deployStream
+	endPoint string
+	buf      []byte
+	sync.Mutex
+}
+
+func (w *deployStreamWriter) Write(p []byte) (int, error) {
+	w.Lock()
+	defer w.Unlock()
+
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+
+		w.stream.sendLine(w.endPoint, string(w.buf[:i]))
+		w.buf = w.buf[i+1:]
+	}
+
+	return len(p), nil
+}
+
+func (w *deployStreamWriter) Flush() {
+	w.Lock()
+	defer w.Unlock()
+
+	if len(w.buf) != 0 {
+		w.stream.sendLine(w.endPoint, string(w.buf))
+		w.buf = nil
+	}
+}
@@ -0,0 +1,230 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mcuadros/dockership/config"
+	"github.com/mcuadros/dockership/core"
+	"github.com/mcuadros/dockership/store"
+)
+
+var (
+	ErrInvalidToken   = errors.New("Invalid or expired token")
+	ErrMissingName    = errors.New("Missing token name")
+	ErrInvalidExpires = errors.New("Invalid expires, a duration, a unix timestamp or a RFC3339 date is expected")
+)
+
+type userKey struct{}
+
+// TokenResult is the token as is returned by the API, the secret is only
+// returned when the token is created
+type TokenResult struct {
+	*store.Token
+	Secret string `json:",omitempty"`
+}
+
+func newTokenResult(t *store.Token, secret string) *TokenResult {
+	c := *t
+	c.Hash = ""
+
+	return &TokenResult{Token: &c, Secret: secret}
+}
+
+// authenticateToken returns the request with the user of the bearer token at
+// the Authorization header
+func (s *server) authenticateToken(r *http.Request, secret string) (*http.Request, error) {
+	if s.store == nil {
+		return nil, ErrStoreNotAvailable
+	}
+
+	t, err := s.store.GetTokenBySecret(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.IsExpired(time.Now()) {
+		return nil, ErrInvalidToken
+	}
+
+	user := &User{Login: t.Owner, Fullname: t.Name, token: t}
+	return r.WithContext(context.WithValue(r.Context(), userKey{}, user)), nil
+}
+
+func getBearerToken(r *http.Request) string {
+	h := r.Header.Get("Authorization")
+	if len(h) < 7 || !strings.EqualFold(h[:7], "bearer ") {
+		return ""
+	}
+
+	return strings.TrimSpace(h[7:])
+}
+
+func (s *server) HandleTokens(w http.ResponseWriter, r *http.Request) {
+	if !s.canManageTokens(w, r) {
+		return
+	}
+
+	l, err := s.store.FindTokens()
+	if err != nil {
+		s.jsonError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	result := make([]*TokenResult, 0)
+	for _, t := range l {
+		result = append(result, newTokenResult(t, ""))
+	}
+
+	s.json(w, http.StatusOK, result)
+}
+
+// HandleCreateToken creates a token from the name, owner, role, project,
+// environment and expires form values, the project and environment can be
+// given multiple times
+func (s *server) HandleCreateToken(w http.ResponseWriter, r *http.Request) {
+	if !s.canManageTokens(w, r) {
+		return
+	}
+
+	user := s.oauth.getUser(r)
+	t, err := s.parseToken(r)
+	if err == nil {
+		t.CreatedBy = user.String()
+		if t.Owner == "" {
+			t.Owner = user.String()
+		}
+	}
+
+	var secret string
+	if err == nil {
+		secret, err = store.NewTokenSecret()
+	}
+
+	if err != nil {
+		s.audit(r, user, ActionTokenCreate, map[string]string{"name": r.FormValue("name")}, err)
+		s.jsonError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	t.Hash = store.HashToken(secret)
+	err = s.store.AddToken(t)
+	s.audit(r, user, ActionTokenCreate, map[string]string{
+		"id":           strconv.FormatUint(t.ID, 10),
+		"name":         t.Name,
+		"owner":        t.Owner,
+		"role":         t.Role,
+		"projects":     strings.Join(t.Projects, ","),
+		"environments": strings.Join(t.Environments, ","),
+	}, err)
+
+	if err != nil {
+		s.jsonError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	core.Info("API token created", "id", t.ID, "name", t.Name, "owner", t.Owner, "user", user)
+	s.json(w, http.StatusCreated, newTokenResult(t, secret))
+}
+
+func (s *server) HandleRevokeToken(w http.ResponseWriter, r *http.Request, id string) {
+	if !s.canManageTokens(w, r) {
+		return
+	}
+
+	user := s.oauth.getUser(r)
+	err := store.ErrNotFound
+	if n, perr := strconv.ParseUint(id, 10, 64); perr == nil {
+		err = s.store.DeleteToken(n)
+	}
+
+	s.audit(r, user, ActionTokenRevoke, map[string]string{"id": id}, err)
+	if err != nil {
+		s.jsonError(w, getStoreErrorStatus(err), err)
+		return
+	}
+
+	core.Info("API token revoked", "id", id, "user", user)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// canManageTokens writes the error response if the tokens can't be managed,
+// only admins logged in with a browser session are allowed
+func (s *server) canManageTokens(w http.ResponseWriter, r *http.Request) bool {
+	if s.store == nil {
+		s.jsonError(w, http.StatusServiceUnavailable, ErrStoreNotAvailable)
+		return false
+	}
+
+	user := s.oauth.getUser(r)
+	if !s.isAdmin(user) || (user != nil && user.token != nil) {
+		s.forbiddenRequest(w, r, user)
+		return false
+	}
+
+	return true
+}
+
+func (s *server) parseToken(r *http.Request) (*store.Token, error) {
+	r.ParseForm()
+
+	t := &store.Token{
+		Name:         r.FormValue("name"),
+		Owner:        r.FormValue("owner"),
+		Role:         r.FormValue("role"),
+		Projects:     r.Form["project"],
+		Environments: r.Form["environment"],
+		Created:      time.Now(),
+	}
+
+	if t.Name == "" {
+		return nil, ErrMissingName
+	}
+
+	if t.Role == "" {
+		t.Role = config.RoleDeployer.String()
+	}
+
+	if _, err := config.ParseRole(t.Role); err != nil {
+		return nil, err
+	}
+
+	for _, p := range t.Projects {
+		if _, ok := s.config().Projects[p]; !ok && p != "*" {
+			return nil, ErrProjectNotFound
+		}
+	}
+
+	for _, e := range t.Environments {
+		if _, ok := s.config().Environments[e]; !ok && e != "*" {
+			return nil, ErrEnvironmentNotFound
+		}
+	}
+
+	if expires := r.FormValue("expires"); expires != "" {
+		var err error
+		if t.Expires, err = parseExpires(t.Created, expires); err != nil {
+			return nil, err
+		}
+	}
+
+	return t, nil
+}
+
+// parseExpires accepts a duration from now, as 720h, or a date
+func parseExpires(now time.Time, value string) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil && d > 0 {
+		return now.Add(d), nil
+	}
+
+	t, err := parseTime(value)
+	if err != nil {
+		return time.Time{}, ErrInvalidExpires
+	}
+
+	return t, nil
+}
@@ -0,0 +1,178 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"time"
+
+	"github.com/mcuadros/dockership/config"
+	"github.com/mcuadros/dockership/store"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *HTTPSuite) TestGetTokenRole(c *C) {
+	t := &store.Token{
+		Role:         "deployer",
+		Projects:     []string{"a"},
+		Environments: []string{"testing"},
+	}
+
+	c.Assert(getTokenRole(t, "a", "testing"), Equals, config.RoleDeployer)
+	c.Assert(getTokenRole(t, "a", "live"), Equals, config.RoleNone)
+	c.Assert(getTokenRole(t, "b", "testing"), Equals, config.RoleNone)
+
+	t.Role = "root"
+	c.Assert(getTokenRole(t, "a", "testing"), Equals, config.RoleNone)
+}
+
+func (s *HTTPSuite) TestServer_getRoleWithToken(c *C) {
+	srv := newTestServer(c, false)
+
+	user := &User{Login: "qux", token: &store.Token{Role: "admin", Projects: []string{"b"}}}
+	c.Assert(srv.getRole(user, "b", "live"), Equals, config.RoleAdmin)
+	c.Assert(srv.getRole(user, "a", "live"), Equals, config.RoleNone)
+	c.Assert(srv.isAdmin(user), Equals, false)
+
+	user.token.Projects = nil
+	c.Assert(srv.isAdmin(user), Equals, true)
+}
+
+func (s *HTTPSuite) TestServer_canManageTokens(c *C) {
+	srv := newTestServer(c, false)
+
+	w := httptest.NewRecorder()
+	c.Assert(srv.canManageTokens(w, newRequest("GET", "/rest/tokens", operator, nil)), Equals, false)
+	c.Assert(w.Code, Equals, http.StatusServiceUnavailable)
+
+	srv = newTestServer(c, true)
+	defer srv.store.Close()
+
+	w = httptest.NewRecorder()
+	c.Assert(srv.canManageTokens(w, newRequest("GET", "/rest/tokens", developer, nil)), Equals, false)
+	c.Assert(w.Code, Equals, http.StatusForbidden)
+
+	token := &User{Login: "bar", token: &store.Token{Role: "admin"}}
+	w = httptest.NewRecorder()
+	c.Assert(srv.canManageTokens(w, newRequest("GET", "/rest/tokens", token, nil)), Equals, false)
+	c.Assert(w.Code, Equals, http.StatusForbidden)
+
+	w = httptest.NewRecorder()
+	c.Assert(srv.canManageTokens(w, newRequest("GET", "/rest/tokens", operator, nil)), Equals, true)
+}
+
+func (s *HTTPSuite) TestServer_HandleTokensForbidden(c *C) {
+	srv := newTestServer(c, true)
+	defer srv.store.Close()
+
+	w := httptest.NewRecorder()
+	srv.HandleTokens(w, newRequest("GET", "/rest/tokens", developer, nil))
+	c.Assert(w.Code, Equals, http.StatusForbidden)
+	c.Assert(w.Body.String(), Equals, "{\"Error\":\"Permission denied\"}\n")
+
+	w = httptest.NewRecorder()
+	srv.HandleCreateToken(w, newRequest("POST", "/rest/tokens", nil, url.Values{"name": {"ci"}}))
+	c.Assert(w.Code, Equals, http.StatusForbidden)
+
+	w = httptest.NewRecorder()
+	srv.HandleRevokeToken(w, newRequest("DELETE", "/rest/tokens/1", developer, nil), "1")
+	c.Assert(w.Code, Equals, http.StatusForbidden)
+
+	tokens, err := srv.store.FindTokens()
+	c.Assert(err, IsNil)
+	c.Assert(tokens, HasLen, 0)
+}
+
+func (s *HTTPSuite) TestServer_HandleCreateToken(c *C) {
+	srv := newTestServer(c, true)
+	defer srv.store.Close()
+
+	w := httptest.NewRecorder()
+	srv.HandleCreateToken(w, newRequest("POST", "/rest/tokens", operator, url.Values{
+		"name":        {"ci"},
+		"project":     {"a"},
+		"environment": {"testing"},
+	}))
+
+	c.Assert(w.Code, Equals, http.StatusCreated)
+
+	tokens, err := srv.store.FindTokens()
+	c.Assert(err, IsNil)
+	c.Assert(tokens, HasLen, 1)
+	c.Assert(tokens[0].Owner, Equals, "bar")
+	c.Assert(tokens[0].Role, Equals, "deployer")
+}
+
+func (s *HTTPSuite) TestServer_parseToken(c *C) {
+	srv := newTestServer(c, false)
+
+	_, err := srv.parseToken(newRequest("POST", "/rest/tokens", nil, url.Values{}))
+	c.Assert(err, Equals, ErrMissingName)
+
+	_, err = srv.parseToken(newRequest("POST", "/rest/tokens", nil, url.Values{
+		"name": {"ci"}, "role": {"root"},
+	}))
+	c.Assert(err, ErrorMatches, "Unknown role.*")
+
+	_, err = srv.parseToken(newRequest("POST", "/rest/tokens", nil, url.Values{
+		"name": {"ci"}, "project": {"qux"},
+	}))
+	c.Assert(err, Equals, ErrProjectNotFound)
+
+	_, err = srv.parseToken(newRequest("POST", "/rest/tokens", nil, url.Values{
+		"name": {"ci"}, "environment": {"qux"},
+	}))
+	c.Assert(err, Equals, ErrEnvironmentNotFound)
+
+	_, err = srv.parseToken(newRequest("POST", "/rest/tokens", nil, url.Values{
+		"name": {"ci"}, "expires": {"foo"},
+	}))
+	c.Assert(err, Equals, ErrInvalidExpires)
+
+	t, err := srv.parseToken(newRequest("POST", "/rest/tokens", nil, url.Values{
+		"name": {"ci"}, "project": {"*"}, "environment": {"*", "live"}, "expires": {"1h"},
+	}))
+	c.Assert(err, IsNil)
+	c.Assert(t.Environments, DeepEquals, []string{"*", "live"})
+	c.Assert(t.Expires.After(time.Now()), Equals, true)
+}
+
+func (s *HTTPSuite) TestServer_authenticateToken(c *C) {
+	srv := newTestServer(c, false)
+
+	_, err := srv.authenticateToken(newRequest("GET", "/rest/status", nil, nil), "foo")
+	c.Assert(err, Equals, ErrStoreNotAvailable)
+
+	srv = newTestServer(c, true)
+	defer srv.store.Close()
+
+	valid, _ := store.NewTokenSecret()
+	c.Assert(srv.store.AddToken(&store.Token{
+		Name: "ci", Owner: "qux", Role: "viewer", Hash: store.HashToken(valid),
+	}), IsNil)
+
+	expired, _ := store.NewTokenSecret()
+	c.Assert(srv.store.AddToken(&store.Token{
+		Name: "old", Owner: "qux", Role: "viewer", Hash: store.HashToken(expired),
+		Expires: time.Now().Add(-time.Hour),
+	}), IsNil)
+
+	r, err := srv.authenticateToken(newRequest("GET", "/rest/status", nil, nil), valid)
+	c.Assert(err, IsNil)
+	c.Assert(srv.oauth.getUser(r).Login, Equals, "qux")
+	c.Assert(srv.can(srv.oauth.getUser(r), config.RoleViewer, "b", "live"), Equals, true)
+	c.Assert(srv.can(srv.oauth.getUser(r), config.RoleDeployer, "b", "live"), Equals, false)
+
+	_, err = srv.authenticateToken(newRequest("GET", "/rest/status", nil, nil), expired)
+	c.Assert(err, Equals, ErrInvalidToken)
+
+	_, err = srv.authenticateToken(newRequest("GET", "/rest/status", nil, nil), "foo")
+	c.Assert(err, NotNil)
+
+	w := httptest.NewRecorder()
+	req := newRequest("GET", "/rest/status", nil, nil)
+	req.Header.Set("Authorization", "Bearer "+expired)
+	srv.ServeHTTP(w, req)
+	c.Assert(w.Code, Equals, http.StatusUnauthorized)
+}
@@ -0,0 +1,93 @@
+package store
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/boltdb/bolt"
+)
+
+var auditBucket = []byte("audit")
+
+// AuditEntry records an action made by a user, entries are never modified
+// or removed
+type AuditEntry struct {
+	ID      uint64
+	Time    time.Time
+	User    string
+	IP      string
+	Action  string
+	Params  map[string]string `json:",omitempty"`
+	Success bool
+	Error   string `json:",omitempty"`
+}
+
+type AuditFilter struct {
+	User   string
+	Action string
+	Since  time.Time
+	Until  time.Time
+	Limit  int
+}
+
+func (f *AuditFilter) Match(e *AuditEntry) bool {
+	switch {
+	case f.User != "" && f.User != e.User:
+		return false
+	case f.Action != "" && f.Action != e.Action:
+		return false
+	case !f.Since.IsZero() && e.Time.Before(f.Since):
+		return false
+	case !f.Until.IsZero() && e.Time.After(f.Until):
+		return false
+	}
+
+	return true
+}
+
+// AddAuditEntry appends the entry to the audit log, the ID is assigned to the
+// given AuditEntry
+func (s *Store) AddAuditEntry(e *AuditEntry) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(auditBucket)
+		id, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+
+		e.ID = id
+		raw, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+
+		return b.Put(itob(id), raw)
+	})
+}
+
+// FindAuditEntries returns the entries matching the filter, newest first
+func (s *Store) FindAuditEntries(f *AuditFilter) ([]*AuditEntry, error) {
+	var r []*AuditEntry
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(auditBucket).Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			e := &AuditEntry{}
+			if err := json.Unmarshal(v, e); err != nil {
+				return err
+			}
+
+			if !f.Match(e) {
+				continue
+			}
+
+			r = append(r, e)
+			if f.Limit > 0 && len(r) >= f.Limit {
+				break
+			}
+		}
+
+		return nil
+	})
+
+	return r, err
+}
@@ -0,0 +1,159 @@
+package store
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/mcuadros/dockership/core"
+
+	"github.com/boltdb/bolt"
+)
+
+var (
+	deploysBucket    = []byte("deploys")
+	deployLogsBucket = []byte("deploy_logs")
+)
+
+// Deploy is the record of a deploy, the output is stored apart and can be
+// retrieved with GetDeployLog
+type Deploy struct {
+	ID          uint64
+	Project     string
+	Environment string
+	User        string
+	Ref         string
+	Revision    core.Revision
+	EndPoints   map[string]*core.EndPointReport
+	Errors      []string `json:",omitempty"`
+	Done        bool
+	Start       time.Time
+	End         time.Time
+}
+
+func NewDeploy(project, environment, user string, r *core.DeployReport) *Deploy {
+	d := &Deploy{
+		Project:     project,
+		Environment: environment,
+		User:        user,
+		Ref:         r.Ref,
+		Revision:    r.Revision,
+		EndPoints:   r.EndPoints,
+		Done:        len(r.Errors) == 0,
+	}
+
+	for _, err := range r.Errors {
+		d.Errors = append(d.Errors, err.Error())
+	}
+
+	return d
+}
+
+type DeployFilter struct {
+	Project     string
+	Environment string
+	User        string
+	Since       time.Time
+	Until       time.Time
+	Limit       int
+}
+
+func (f *DeployFilter) Match(d *Deploy) bool {
+	switch {
+	case f.Project != "" && f.Project != d.Project:
+		return false
+	case f.Environment != "" && f.Environment != d.Environment:
+		return false
+	case f.User != "" && f.User != d.User:
+		return false
+	case !f.Since.IsZero() && d.Start.Before(f.Since):
+		return false
+	case !f.Until.IsZero() && d.Start.After(f.Until):
+		return false
+	}
+
+	return true
+}
+
+// AddDeploy stores the deploy and its output, the ID is assigned to the
+// given Deploy
+func (s *Store) AddDeploy(d *Deploy, output []byte) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(deploysBucket)
+		id, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+
+		d.ID = id
+		raw, err := json.Marshal(d)
+		if err != nil {
+			return err
+		}
+
+		if err := b.Put(itob(id), raw); err != nil {
+			return err
+		}
+
+		return tx.Bucket(deployLogsBucket).Put(itob(id), output)
+	})
+}
+
+// FindDeploys returns the deploys matching the filter, newest first
+func (s *Store) FindDeploys(f *DeployFilter) ([]*Deploy, error) {
+	var r []*Deploy
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(deploysBucket).Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			d := &Deploy{}
+			if err := json.Unmarshal(v, d); err != nil {
+				return err
+			}
+
+			if !f.Match(d) {
+				continue
+			}
+
+			r = append(r, d)
+			if f.Limit > 0 && len(r) >= f.Limit {
+				break
+			}
+		}
+
+		return nil
+	})
+
+	return r, err
+}
+
+func (s *Store) GetDeploy(id uint64) (*Deploy, error) {
+	d := &Deploy{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		raw := tx.Bucket(deploysBucket).Get(itob(id))
+		if raw == nil {
+			return ErrNotFound
+		}
+
+		return json.Unmarshal(raw, d)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+func (s *Store) GetDeployLog(id uint64) ([]byte, error) {
+	var r []byte
+	err := s.db.View(func(tx *bolt.Tx) error {
+		raw := tx.Bucket(deployLogsBucket).Get(itob(id))
+		if raw == nil {
+			return ErrNotFound
+		}
+
+		r = append([]byte{}, raw...)
+		return nil
+	})
+
+	return r, err
+}
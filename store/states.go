@@ -0,0 +1,51 @@
+package store
+
+import (
+	"encoding/json"
+
+	"github.com/mcuadros/dockership/core"
+
+	"github.com/boltdb/bolt"
+)
+
+var statesBucket = []byte("desired_states")
+
+// SetDesiredState stores the desired state of the project at the environment,
+// unless the stored one is newer
+func (s *Store) SetDesiredState(state *core.DesiredState) error {
+	key := []byte(state.Project + "." + state.Environment)
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(statesBucket)
+		if raw := b.Get(key); raw != nil {
+			current := &core.DesiredState{}
+			if err := json.Unmarshal(raw, current); err == nil && current.Time.After(state.Time) {
+				return nil
+			}
+		}
+
+		raw, err := json.Marshal(state)
+		if err != nil {
+			return err
+		}
+
+		return b.Put(key, raw)
+	})
+}
+
+// FindDesiredStates returns every stored desired state
+func (s *Store) FindDesiredStates() ([]*core.DesiredState, error) {
+	var r []*core.DesiredState
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(statesBucket).ForEach(func(k, v []byte) error {
+			state := &core.DesiredState{}
+			if err := json.Unmarshal(v, state); err != nil {
+				return err
+			}
+
+			r = append(r, state)
+			return nil
+		})
+	})
+
+	return r, err
+}
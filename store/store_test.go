@@ -0,0 +1,213 @@
+package store
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mcuadros/dockership/core"
+
+	. "gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type StoreSuite struct {
+	dir   string
+	store *Store
+}
+
+var _ = Suite(&StoreSuite{})
+
+func (s *StoreSuite) SetUpTest(c *C) {
+	s.dir, _ = ioutil.TempDir("", "dockership")
+
+	var err error
+	s.store, err = Open(filepath.Join(s.dir, "db", "dockership.db"))
+	c.Assert(err, IsNil)
+}
+
+func (s *StoreSuite) TearDownTest(c *C) {
+	s.store.Close()
+	os.RemoveAll(s.dir)
+}
+
+func (s *StoreSuite) TestStore_AddDeploy(c *C) {
+	d := NewDeploy("foo", "bar", "qux", &core.DeployReport{
+		Ref:      "master",
+		Revision: core.Revision{"foo/bar": "baz"},
+		EndPoints: map[string]*core.EndPointReport{
+			"tcp://foo": &core.EndPointReport{Built: true, Replaced: true},
+		},
+	})
+
+	c.Assert(s.store.AddDeploy(d, []byte("output")), IsNil)
+	c.Assert(d.ID, Equals, uint64(1))
+
+	r, err := s.store.GetDeploy(1)
+	c.Assert(err, IsNil)
+	c.Assert(r.Project, Equals, "foo")
+	c.Assert(r.Environment, Equals, "bar")
+	c.Assert(r.User, Equals, "qux")
+	c.Assert(r.Ref, Equals, "master")
+	c.Assert(r.Done, Equals, true)
+	c.Assert(r.Revision.Get(), Equals, "baz")
+	c.Assert(r.EndPoints["tcp://foo"].Replaced, Equals, true)
+
+	output, err := s.store.GetDeployLog(1)
+	c.Assert(err, IsNil)
+	c.Assert(string(output), Equals, "output")
+}
+
+func (s *StoreSuite) TestStore_GetDeployNotFound(c *C) {
+	_, err := s.store.GetDeploy(42)
+	c.Assert(err, Equals, ErrNotFound)
+
+	_, err = s.store.GetDeployLog(42)
+	c.Assert(err, Equals, ErrNotFound)
+}
+
+func (s *StoreSuite) TestStore_FindDeploys(c *C) {
+	now := time.Now()
+	for i, p := range []string{"foo", "bar", "foo"} {
+		d := NewDeploy(p, "qux", "baz", &core.DeployReport{Errors: []error{errors.New("foo")}})
+		d.Start = now.Add(time.Duration(i) * time.Hour)
+		c.Assert(s.store.AddDeploy(d, nil), IsNil)
+	}
+
+	l, err := s.store.FindDeploys(&DeployFilter{})
+	c.Assert(err, IsNil)
+	c.Assert(l, HasLen, 3)
+	c.Assert(l[0].ID, Equals, uint64(3))
+	c.Assert(l[0].Done, Equals, false)
+	c.Assert(l[0].Errors, DeepEquals, []string{"foo"})
+
+	l, err = s.store.FindDeploys(&DeployFilter{Project: "foo"})
+	c.Assert(err, IsNil)
+	c.Assert(l, HasLen, 2)
+
+	l, err = s.store.FindDeploys(&DeployFilter{Since: now.Add(30 * time.Minute)})
+	c.Assert(err, IsNil)
+	c.Assert(l, HasLen, 2)
+
+	l, err = s.store.FindDeploys(&DeployFilter{Until: now.Add(30 * time.Minute)})
+	c.Assert(err, IsNil)
+	c.Assert(l, HasLen, 1)
+
+	l, err = s.store.FindDeploys(&DeployFilter{Limit: 1, User: "baz"})
+	c.Assert(err, IsNil)
+	c.Assert(l, HasLen, 1)
+
+	l, err = s.store.FindDeploys(&DeployFilter{Environment: "foo"})
+	c.Assert(err, IsNil)
+	c.Assert(l, HasLen, 0)
+}
+
+func (s *StoreSuite) TestStore_AuditEntries(c *C) {
+	now := time.Now()
+	for i, action := range []string{"deploy", "task", "deploy"} {
+		e := &AuditEntry{
+			Time:    now.Add(time.Duration(i) * time.Hour),
+			User:    "foo",
+			Action:  action,
+			Params:  map[string]string{"project": "bar"},
+			Success: true,
+		}
+
+		c.Assert(s.store.AddAuditEntry(e), IsNil)
+		c.Assert(e.ID, Equals, uint64(i+1))
+	}
+
+	l, err := s.store.FindAuditEntries(&AuditFilter{})
+	c.Assert(err, IsNil)
+	c.Assert(l, HasLen, 3)
+	c.Assert(l[0].ID, Equals, uint64(3))
+	c.Assert(l[0].Params["project"], Equals, "bar")
+
+	l, err = s.store.FindAuditEntries(&AuditFilter{Action: "deploy"})
+	c.Assert(err, IsNil)
+	c.Assert(l, HasLen, 2)
+
+	l, err = s.store.FindAuditEntries(&AuditFilter{User: "bar"})
+	c.Assert(err, IsNil)
+	c.Assert(l, HasLen, 0)
+
+	l, err = s.store.FindAuditEntries(&AuditFilter{Since: now.Add(30 * time.Minute), Limit: 1})
+	c.Assert(err, IsNil)
+	c.Assert(l, HasLen, 1)
+	c.Assert(l[0].Action, Equals, "deploy")
+}
+
+func (s *StoreSuite) TestStore_Tokens(c *C) {
+	secret, err := NewTokenSecret()
+	c.Assert(err, IsNil)
+	c.Assert(secret, HasLen, 64)
+
+	t := &Token{Name: "ci", Owner: "foo", Role: "deployer", Hash: HashToken(secret)}
+	c.Assert(s.store.AddToken(t), IsNil)
+	c.Assert(t.ID, Equals, uint64(1))
+	c.Assert(t.Hash, Not(Equals), secret)
+
+	r, err := s.store.GetTokenBySecret(secret)
+	c.Assert(err, IsNil)
+	c.Assert(r.Name, Equals, "ci")
+	c.Assert(r.Owner, Equals, "foo")
+
+	_, err = s.store.GetTokenBySecret("foo")
+	c.Assert(err, Equals, ErrNotFound)
+
+	c.Assert(s.store.DeleteToken(1), IsNil)
+	c.Assert(s.store.DeleteToken(1), Equals, ErrNotFound)
+
+	_, err = s.store.GetTokenBySecret(secret)
+	c.Assert(err, Equals, ErrNotFound)
+}
+
+func (s *StoreSuite) TestToken_IsExpired(c *C) {
+	now := time.Now()
+
+	t := &Token{}
+	c.Assert(t.IsExpired(now), Equals, false)
+
+	t.Expires = now.Add(-time.Hour)
+	c.Assert(t.IsExpired(now), Equals, true)
+
+	t.Expires = now.Add(time.Hour)
+	c.Assert(t.IsExpired(now), Equals, false)
+}
+
+func (s *StoreSuite) TestToken_Match(c *C) {
+	t := &Token{}
+	c.Assert(t.Match("foo", "bar"), Equals, true)
+
+	t.Projects = []string{"foo"}
+	t.Environments = []string{"bar"}
+	c.Assert(t.Match("foo", "bar"), Equals, true)
+	c.Assert(t.Match("foo", "qux"), Equals, false)
+	c.Assert(t.Match("qux", "bar"), Equals, false)
+}
+
+func (s *StoreSuite) TestStore_SetDesiredState(c *C) {
+	now := time.Now()
+	c.Assert(s.store.SetDesiredState(&core.DesiredState{
+		Project: "foo", Environment: "bar", Image: "foo:new", Time: now,
+	}), IsNil)
+
+	c.Assert(s.store.SetDesiredState(&core.DesiredState{
+		Project: "foo", Environment: "bar", Image: "foo:old", Time: now.Add(-time.Minute),
+	}), IsNil)
+
+	c.Assert(s.store.SetDesiredState(&core.DesiredState{
+		Project: "foo", Environment: "qux", Image: "foo:qux", Time: now,
+	}), IsNil)
+
+	states, err := s.store.FindDesiredStates()
+	c.Assert(err, IsNil)
+	c.Assert(states, HasLen, 2)
+	c.Assert(states[0].Environment, Equals, "bar")
+	c.Assert(states[0].Image, Equals, core.ImageID("foo:new"))
+	c.Assert(states[1].Environment, Equals, "qux")
+}
@@ -0,0 +1,138 @@
+package store
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/boltdb/bolt"
+)
+
+var tokensBucket = []byte("tokens")
+
+// Token is a long-lived API token, only the hash of the secret is stored
+type Token struct {
+	ID           uint64
+	Name         string
+	Owner        string
+	Role         string
+	Projects     []string `json:",omitempty"`
+	Environments []string `json:",omitempty"`
+	CreatedBy    string
+	Created      time.Time
+	Expires      time.Time
+	Hash         string `json:",omitempty"`
+}
+
+// IsExpired returns true if the token has an expiry and it is before now
+func (t *Token) IsExpired(now time.Time) bool {
+	return !t.Expires.IsZero() && now.After(t.Expires)
+}
+
+// Match returns true if the project and environment are in the scope of the
+// token, with no projects or environments the token is valid for all
+func (t *Token) Match(project, environment string) bool {
+	return matchScope(t.Projects, project) && matchScope(t.Environments, environment)
+}
+
+func matchScope(list []string, value string) bool {
+	if len(list) == 0 {
+		return true
+	}
+
+	for _, v := range list {
+		if v == "*" || v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NewTokenSecret returns a random secret, to be given to the token owner
+func NewTokenSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// HashToken returns the hash of the secret as is stored
+func HashToken(secret string) string {
+	h := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(h[:])
+}
+
+// AddToken stores the token, the ID is assigned to the given Token
+func (s *Store) AddToken(t *Token) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(tokensBucket)
+		id, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+
+		t.ID = id
+		raw, err := json.Marshal(t)
+		if err != nil {
+			return err
+		}
+
+		return b.Put(itob(id), raw)
+	})
+}
+
+// FindTokens returns all the tokens, newest first
+func (s *Store) FindTokens() ([]*Token, error) {
+	var r []*Token
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(tokensBucket).Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			t := &Token{}
+			if err := json.Unmarshal(v, t); err != nil {
+				return err
+			}
+
+			r = append(r, t)
+		}
+
+		return nil
+	})
+
+	return r, err
+}
+
+// GetTokenBySecret returns the token with the given secret, ErrNotFound is
+// returned if none matches
+func (s *Store) GetTokenBySecret(secret string) (*Token, error) {
+	hash := []byte(HashToken(secret))
+	l, err := s.FindTokens()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, t := range l {
+		if subtle.ConstantTimeCompare([]byte(t.Hash), hash) == 1 {
+			return t, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+// DeleteToken removes the token, ErrNotFound is returned if it doesn't exist
+func (s *Store) DeleteToken(id uint64) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(tokensBucket)
+		if b.Get(itob(id)) == nil {
+			return ErrNotFound
+		}
+
+		return b.Delete(itob(id))
+	})
+}